@@ -1,3 +1,8 @@
 module github.com/SeanTolstoyevski/paket
 
-go 1.15
+go 1.16
+
+require (
+	github.com/klauspost/compress v1.13.1
+	golang.org/x/crypto v0.0.0-20210616213533-5ff15b29337e
+)
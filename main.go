@@ -13,9 +13,13 @@ import (
 	"fmt"
 
 	paket "github.com/SeanTolstoyevski/paket/pengine"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -27,16 +31,58 @@ var (
 	keyvalue        = flag.String("k", "", "Key for encrypting files. It must be 16, 24 or 32 length in bytes. If this parameter is null, the tool generates one randomly byte  and prints value to the console.")
 	tablefile       = flag.String("t", "PaketTable.go", "The go file to be written for Paket to read. When compiling this file, you must import it into your program.\nIt is created as \"package main.\"")
 	showprogressval = flag.Bool("s", true, "prints progress steps to the console. For example, which file is currently encrypting, etc.")
+	minimal         = flag.Bool("m", false, "writes only the positions and lengths to the table, without hashes. For the smallest tables. Hash checks of Paket always fail for these files.")
+	syncOutput      = flag.Bool("sync", false, "flushes the paket, the table and their folders to the disk before finishing. Use it on systems that can lose power (embedded devices, flash storage). Packing is slower, especially on slow disks.")
+	passwordvalue   = flag.String("password", "", "Password to derive the key from, instead of -k. The salt is written to the table file as PaketSalt. Read it with pengine.DeriveKey(password, PaketSalt, 32).")
+	compressvalue   = flag.Bool("c", false, "compresses the files with gzip before encryption. Files that don't get smaller (audio, images...) are stored without compression. Same as -compress gzip.")
+	compressionname = flag.String("compress", "none", "Compression of the files before encryption: none, gzip or zstd. zstd is smaller and faster than gzip. Files that don't get smaller are stored without compression.")
+	hashvalue       = flag.String("hash", "sha256", "Hash algorithm of the table: sha256, sha512 or blake2b.")
+	embedvalue      = flag.Bool("embed", false, "writes the table to the beginning of the paket file instead of a go file. Open it with pengine.OpenSelfDescribing, no table file is created.")
+	modevalue       = flag.String("mode", "cfb", "Encryption mode: cfb or gcm. gcm detects modified data and wrong keys. For gcm pakets, set the Mode of Paket to ModeGCM when reading.")
+	metakeyvalue    = flag.String("metakey", "", "Key of the table MAC and the file MACs (-mac), if it must be different from the key. Readers set it as MetaKey of Paket. Can't be used with -embed.")
+	macvalue        = flag.Bool("mac", false, "writes an HMAC of every file to the table. Unlike the hashes, it can't be recalculated without the key. GetFile checks it.")
+	splitvalue      = flag.Int64("split", 0, "splits the paket into volumes of this size in bytes, named like data.pack.001, data.pack.002... Open them with pengine.NewVolumes. 0 means no split. Can't be used with -embed.")
+	deterministic   = flag.Bool("deterministic", false, "derives the IVs from the key and the files instead of random ones. The same files and key give the same paket, for reproducible builds. Files with the same content can be recognized in the paket.")
+	entrykeys       = flag.Bool("entrykeys", false, "encrypts every file with its own key, derived from the key and the name of the file. A leaked file key doesn't decrypt the other files. Set PerEntryKeys of Paket to true when reading.")
+	infovalue       = flag.String("info", "", "prints the summary of a self-describing paket (created with -embed) and exits: number of files, sizes and compression. No key is needed.")
+	bindnames       = flag.Bool("bindnames", false, "authenticates the name of every file with its data, so files can't be swapped in the table. Needs -mode gcm.")
+	selftest        = flag.Bool("selftest", false, "reads every file back after writing it, decrypts it and compares it with the original. Stops on a mismatch. Slower, for release builds. Can't be used with -split.")
+	encryptnames    = flag.Bool("encryptnames", false, "hides the names of the files in the table, only their encrypted form and an HMAC of them are written. Readers set EncryptedNames of pengine.OpenOptions, or call Paket.DecryptNames.")
+	streamvalue     = flag.Int64("stream", 0, "files larger than this size in bytes are encrypted while they are written, instead of being loaded to memory. For files larger than the memory. They are not compressed. 0 means never. Needs -mode cfb, can't be used with -deterministic.")
+	indexvalue      = flag.String("index", "", "also writes the table as JSON to this file, e.g. data.idx. Read it with pengine.LoadTable at run time, so the program doesn't need the go file of the table. Can't be used with -embed.")
+	plainvalue      = flag.String("plain", "", "comma separated patterns of the files stored without encryption, like \"*.txt,LICENSE\". For public files that must be read fast. The table MAC covers which files are plain.")
+	metavalue       = flag.String("meta", "", "comma separated name=file pairs of metadata blobs, like \"license=LICENSE,buildlog=build.txt\". They are encrypted and written to the header, not to the table. Read them with Paket.GetMetadataBlob. Needs -embed.")
+	checksumvalue   = flag.Bool("checksum", false, "writes the sha256 of the paket file to a file next to it (data.pack.sha256, in sha256sum format) and as PaketChecksum to the table file. Check downloads with it. Can't be used with -split.")
+	workers         = flag.Int("w", 0, "Number of files encrypted at the same time. 0 means the number of CPUs. The output is the same for every value, only the speed changes.")
 )
 
 func main() {
+	if flag.Arg(0) == "check-source" {
+		checkSource(flag.Args()[1:])
+		return
+	}
+	if *infovalue != "" {
+		printInfo(*infovalue)
+		return
+	}
 	if *foldername == "" {
 		fmt.Println("\"-fn\" parameter cannot be null.\nSee", os.Args[0], "-help")
 		os.Exit(1)
 	}
-	var useKey []byte
+	var useKey, salt []byte
 
-	if *keyvalue == "" {
+	if *passwordvalue != "" {
+		if *keyvalue != "" {
+			fmt.Println("\"-k\" and \"-password\" cannot be used together.")
+			os.Exit(1)
+		}
+		var err error
+		salt, err = paket.CreateSalt()
+		errHandler(err)
+		useKey, err = paket.DeriveKey([]byte(*passwordvalue), salt, 32)
+		errHandler(err)
+		fmt.Println("The key is derived from your password.")
+	} else if *keyvalue == "" {
 		useKey = []byte(keyDefault[:32])
 		fmt.Printf("Your random key: %s\n", keyDefault[:32])
 	} else {
@@ -44,28 +90,131 @@ func main() {
 		fmt.Printf("Your key is: %s\n", *keyvalue)
 	}
 
-	if !confirmatorLen(len(useKey)) {
-		fmt.Println("Wrong key length", len(useKey))
+	if err := paket.ValidKeyLength(useKey); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	if paket.Exists(*outputfile) {
-		fmt.Printf("There is a file with this name (%s). You can rerun cmd tool  under a different name, rename the existing file, or delete it.", *outputfile)
+	metaKey := useKey
+	if *metakeyvalue != "" {
+		if *embedvalue {
+			fmt.Println("\"-metakey\" and \"-embed\" cannot be used together.")
+			os.Exit(1)
+		}
+		metaKey = []byte(*metakeyvalue)
+	}
+
+	mode := paket.ModeCFB
+	switch *modevalue {
+	case "cfb":
+	case "gcm":
+		mode = paket.ModeGCM
+		if !*embedvalue {
+			fmt.Println("gcm mode. Set the Mode of Paket to pengine.ModeGCM when reading.")
+		}
+	default:
+		fmt.Println("Unknown mode", *modevalue)
 		os.Exit(1)
 	}
-	if paket.Exists(*tablefile) {
-		fmt.Println("The table file will be recreate.")
+	if *bindnames && mode != paket.ModeGCM {
+		fmt.Println("\"-bindnames\" needs \"-mode gcm\".")
+		os.Exit(1)
 	}
 
-	gotablefile, err := os.Create(*tablefile)
-	defer gotablefile.Close()
-	errHandler(err)
+	_, err := paket.HashByName(*hashvalue)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	compression, err := paket.CompressionByName(*compressionname)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *compressvalue && compression == paket.CompressionNone {
+		compression = paket.CompressionGzip
+	}
 
-	packFile, err := os.OpenFile(*outputfile, os.O_RDWR|os.O_CREATE, 0666)
-	defer packFile.Close()
+	if *splitvalue < 0 || (*splitvalue > 0 && *embedvalue) {
+		fmt.Println("\"-split\" must be positive and cannot be used with \"-embed\".")
+		os.Exit(1)
+	}
+	if *checksumvalue && *splitvalue > 0 {
+		fmt.Println("\"-checksum\" and \"-split\" cannot be used together.")
+		os.Exit(1)
+	}
+	if *streamvalue < 0 || (*streamvalue > 0 && (mode != paket.ModeCFB || *deterministic)) {
+		fmt.Println("\"-stream\" must be positive, it needs \"-mode cfb\" and cannot be used with \"-deterministic\".")
+		os.Exit(1)
+	}
+	if *indexvalue != "" && *embedvalue {
+		fmt.Println("\"-index\" and \"-embed\" cannot be used together. The table is in the paket with \"-embed\".")
+		os.Exit(1)
+	}
+	if *selftest && *splitvalue > 0 {
+		fmt.Println("\"-selftest\" and \"-split\" cannot be used together.")
+		os.Exit(1)
+	}
+	// metadata blobs are read before packing, so a wrong file name doesn't waste the packing.
+	var blobNames []string
+	blobs := map[string][]byte{}
+	if *metavalue != "" {
+		if !*embedvalue {
+			fmt.Println("\"-meta\" needs \"-embed\". Only self-describing pakets have a header for the metadata.")
+			os.Exit(1)
+		}
+		for _, pair := range strings.Split(*metavalue, ",") {
+			name, path := pair, ""
+			if i := strings.Index(pair, "="); i >= 0 {
+				name, path = strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+			}
+			if name == "" || path == "" {
+				fmt.Printf("%q is not a name=file pair.\n", pair)
+				os.Exit(1)
+			}
+			data, err := ioutil.ReadFile(path)
+			errHandler(err)
+			blobNames = append(blobNames, name)
+			blobs[name] = data
+		}
+	}
+	firstOutput := *outputfile
+	if *splitvalue > 0 {
+		firstOutput = paket.VolumeName(*outputfile, 1)
+	}
+	found, err := paket.FileExists(firstOutput)
 	errHandler(err)
+	if found {
+		fmt.Printf("There is a file with this name (%s). You can rerun cmd tool  under a different name, rename the existing file, or delete it.", firstOutput)
+		os.Exit(1)
+	}
+	var gotablefile *os.File
+	var tableOut io.Writer = ioutil.Discard
+	if !*embedvalue {
+		found, err := paket.FileExists(*tablefile)
+		errHandler(err)
+		if found {
+			fmt.Println("The table file will be recreate.")
+		}
+		gotablefile, err = os.Create(*tablefile)
+		errHandler(err)
+		defer gotablefile.Close()
+		tableOut = gotablefile
+	}
 
-	var start, full, end int
+	// With -embed, the table is written to the header of the paket. The header must be before the data,
+	// but it can only be created after all files are encrypted. So the data is written to a temporary file first.
+	var dataFile *os.File
+	var volumes *paket.VolumeWriter
+	if *splitvalue > 0 {
+		volumes, err = paket.NewVolumeWriter(*outputfile, *splitvalue)
+		errHandler(err)
+	} else if *embedvalue {
+		dataFile, err = os.CreateTemp(filepath.Dir(*outputfile), "."+filepath.Base(*outputfile)+".*.tmp")
+		errHandler(err)
+		defer os.Remove(dataFile.Name())
+		defer dataFile.Close()
+	}
 
 	listFiles, err := ioutil.ReadDir(*foldername)
 	errHandler(err)
@@ -73,31 +222,279 @@ func main() {
 	if show {
 		fmt.Printf("%d files were found in %s folder.\n", len(listFiles), *foldername)
 	}
-	gotablefile.Write([]byte(toptemplate))
+	tableOut.Write([]byte(toptemplate))
+
+	names := make([]string, 0, len(listFiles))
+	paths := make([]string, 0, len(listFiles))
+	sizes := make(map[string]int64, len(listFiles))
 	for _, file := range listFiles {
 		if !file.IsDir() {
-			name := file.Name()
-			if show {
-				fmt.Printf("%s file is encrypting. Size: %0.03f MB\n", name, float64(file.Size())/1024.0/1024.0)
+			names = append(names, file.Name())
+			paths = append(paths, filepath.Join(*foldername, file.Name()))
+			sizes[file.Name()] = file.Size()
+		}
+	}
+	opts := paket.PackOptions{Mode: mode, Hash: *hashvalue, Compression: compression, Minimal: *minimal, MAC: *macvalue, MetaKey: metaKey, Deterministic: *deterministic, PerEntryKeys: *entrykeys, BindNames: *bindnames, SelfTest: *selftest, StreamSize: *streamvalue, EncryptNames: *encryptnames, Workers: *workers, Sync: *syncOutput}
+	if *plainvalue != "" {
+		for _, pattern := range strings.Split(*plainvalue, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				opts.Plain = append(opts.Plain, pattern)
 			}
-			content, err := ioutil.ReadFile(*foldername + "/" + name)
-			errHandler(err)
-			orgLen := len(content)
-			encData, err := paket.Encrypt(useKey, content)
-			errHandler(err)
-			encLen := len(encData)
-			originalHash := fmt.Sprintf("%x", sha256.Sum256(content))
-			EncryptedHash := fmt.Sprintf("%x", sha256.Sum256(encData))
-			_, rerr := packFile.Write(encData)
-			errHandler(rerr)
-			start = full
-			full += encLen
-			end = full
+		}
+	}
+	if show {
+		opts.Progress = func(name string, done, total int) {
+			fmt.Printf("%s file is encrypted (%d/%d). Size: %0.03f MB\n", name, done, total, float64(sizes[name])/1024.0/1024.0)
+		}
+	}
+	// the paket is written to a temporary file and renamed, so a failed packing doesn't leave a truncated paket.
+	var table paket.Datas
+	switch {
+	case volumes != nil:
+		table, err = paket.Pack(volumes, useKey, paths, opts)
+	case dataFile != nil:
+		table, err = paket.Pack(dataFile, useKey, paths, opts)
+	default:
+		table, err = paket.PackFile(*outputfile, useKey, paths, opts)
+	}
+	errHandler(err)
+
+	if *encryptnames {
+		// the names in the table are the IDs of the names now.
+		names = names[:0]
+		for id := range table {
+			names = append(names, id)
+		}
+		sort.Strings(names)
+		if !*embedvalue {
+			fmt.Println("The names are encrypted. Set EncryptedNames of pengine.OpenOptions when reading.")
+		}
+	}
+	for _, name := range names {
+		v := table[name]
+		start, end := strconv.FormatInt(v.StartPos, 10), strconv.FormatInt(v.EndPos, 10)
+		orgLen, encLen := strconv.FormatInt(v.OriginalLenght, 10), strconv.FormatInt(v.EncryptLenght, 10)
 
-			gotablefile.Write([]byte(fmt.Sprintf(goTemplate, name, strconv.Itoa(start), strconv.Itoa(end), strconv.Itoa(orgLen), strconv.Itoa(encLen), originalHash, EncryptedHash)))
+		// optional fields, written only when they are not the zero value.
+		extra := ""
+		switch v.Compression {
+		case paket.CompressionGzip:
+			extra += ", Compression : paket.CompressionGzip"
+		case paket.CompressionZstd:
+			extra += ", Compression : paket.CompressionZstd"
+		}
+		if v.HashAlgorithm != "" {
+			extra += fmt.Sprintf(", HashAlgorithm : %q", v.HashAlgorithm)
+		}
+		if v.ModTime != 0 {
+			extra += fmt.Sprintf(", ModTime : %d", v.ModTime)
+		}
+		if v.CRC != 0 {
+			extra += fmt.Sprintf(", CRC : %d", v.CRC)
+		}
+		if v.MAC != "" {
+			extra += fmt.Sprintf(", MAC : %q", v.MAC)
+		}
+		if v.NameAAD {
+			extra += ", NameAAD : true"
+		}
+		if v.EncName != "" {
+			extra += fmt.Sprintf(", EncName : %q", v.EncName)
+		}
+		if v.Unencrypted {
+			extra += ", Unencrypted : true"
 		}
+		if *minimal {
+			tableOut.Write([]byte(fmt.Sprintf(goMinimalTemplate, name, start, end, orgLen, encLen, extra)))
+		} else {
+			tableOut.Write([]byte(fmt.Sprintf(goTemplate, name, start, end, orgLen, encLen, v.HashOriginal, v.HashEncrypt, extra)))
+		}
+	}
+	tableOut.Write([]byte("}"))
+	tableOut.Write([]byte(fmt.Sprintf(macTemplate, paket.TableMAC(metaKey, table))))
+	tableOut.Write([]byte(fmt.Sprintf(keyCheckTemplate, paket.KeyCheckValue(useKey))))
+	tableOut.Write([]byte(fmt.Sprintf(readerVersionTemplate, paket.MinReaderVersion(table, *entrykeys, *encryptnames))))
+	if salt != nil {
+		tableOut.Write([]byte(fmt.Sprintf(saltTemplate, salt)))
+	}
+
+	var indexFile *os.File
+	if *indexvalue != "" {
+		indexFile, err = os.Create(*indexvalue)
+		errHandler(err)
+		defer indexFile.Close()
+		errHandler(paket.WriteTableJSON(indexFile, table))
 	}
-	gotablefile.Write([]byte("}"))
+
+	if *embedvalue {
+		h := paket.Header{Mode: mode, PerEntryKeys: *entrykeys, EncryptedNames: *encryptnames, Salt: salt, Table: table, TableMAC: paket.TableMAC(useKey, table), KeyCheck: paket.KeyCheckValue(useKey)}
+		for _, name := range blobNames {
+			errHandler(h.SetMetadataBlob(useKey, name, blobs[name]))
+		}
+		writeEmbedded(dataFile, h)
+	}
+
+	checksumFile := *outputfile + ".sha256"
+	if *checksumvalue {
+		sum, err := paket.FileChecksum(*outputfile)
+		errHandler(err)
+		tableOut.Write([]byte(fmt.Sprintf(checksumTemplate, sum)))
+		errHandler(ioutil.WriteFile(checksumFile, []byte(fmt.Sprintf("%s  %s\n", sum, filepath.Base(*outputfile))), 0666))
+		if show {
+			fmt.Printf("Checksum (sha256): %s\n", sum)
+		}
+	}
+
+	if volumes != nil {
+		errHandler(volumes.Close())
+		if show {
+			fmt.Printf("The paket is split into %d volumes. Open them with pengine.NewVolumes.\n", len(volumes.Paths()))
+		}
+	}
+
+	if *syncOutput {
+		if volumes != nil {
+			for _, path := range volumes.Paths() {
+				errHandler(paket.SyncFile(path))
+			}
+		}
+		if *checksumvalue {
+			errHandler(paket.SyncFile(checksumFile))
+		}
+		// PackFile syncs the folder of the paket itself. The others are written here.
+		if volumes != nil || *embedvalue || *checksumvalue {
+			errHandler(paket.SyncDir(filepath.Dir(*outputfile)))
+		}
+		if indexFile != nil {
+			errHandler(indexFile.Sync())
+			errHandler(paket.SyncDir(filepath.Dir(*indexvalue)))
+		}
+		if gotablefile != nil {
+			errHandler(gotablefile.Sync())
+			errHandler(paket.SyncDir(filepath.Dir(*tablefile)))
+		}
+	}
+}
+
+// printInfo prints the summary of the table in the header of a self-describing paket.
+func printInfo(name string) {
+	f, err := os.Open(name)
+	errHandler(err)
+	defer f.Close()
+	h, _, err := paket.ReadHeader(f)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	info := h.Table.Info()
+	fmt.Printf("Files: %d\n", info.Count)
+	if h.MinReaderVersion > 0 {
+		fmt.Printf("Minimum reader version: %d\n", h.MinReaderVersion)
+	}
+	fmt.Printf("Original size: %d bytes\n", info.OriginalSize)
+	fmt.Printf("Encrypted size: %d bytes\n", info.EncryptedSize)
+	if info.Compressed > 0 {
+		fmt.Printf("Compressed files: %d (%.1f%% of their original size)\n", info.Compressed, info.CompressionRatio*100)
+	}
+	if info.Count > 0 {
+		fmt.Printf("Largest file: %s (%d bytes)\n", info.Largest, h.Table[info.Largest].OriginalLenght)
+		fmt.Printf("Smallest file: %s (%d bytes)\n", info.Smallest, h.Table[info.Smallest].OriginalLenght)
+	}
+}
+
+// writeEmbedded creates the output file with the header and copies the data from the temporary file.
+// Like pengine.PackFile, it is written to another temporary file and renamed. With -sync, it is flushed to the disk before the rename.
+// The temporary file is removed. Returns the output file.
+func writeEmbedded(dataFile *os.File, h paket.Header) {
+	out, err := os.CreateTemp(filepath.Dir(*outputfile), "."+filepath.Base(*outputfile)+".*.tmp")
+	errHandler(err)
+	// after the rename, there is nothing to remove.
+	defer os.Remove(out.Name())
+	defer out.Close()
+	_, err = paket.WriteHeader(out, h)
+	errHandler(err)
+	_, err = dataFile.Seek(0, io.SeekStart)
+	errHandler(err)
+	_, err = io.Copy(out, dataFile)
+	errHandler(err)
+	errHandler(out.Chmod(0644))
+	if *syncOutput {
+		errHandler(out.Sync())
+	}
+	errHandler(out.Close())
+	errHandler(os.Rename(out.Name(), *outputfile))
+}
+
+// checkSource is the check-source command. It compares a paket with the folder it was created from (see pengine.DiffAgainstDir)
+// and exits with 1 if the paket must be rebuilt, so it can be used in CI.
+func checkSource(args []string) {
+	cmd := flag.NewFlagSet("check-source", flag.ExitOnError)
+	dir := cmd.String("f", "", "Folder the paket was created from.")
+	paketName := cmd.String("o", "data.pack", "The paket file.")
+	index := cmd.String("index", "", "JSON table of the paket (written with -index). If it is empty, the paket must be self-describing (created with -embed).")
+	key := cmd.String("k", "", "Key of the paket.")
+	password := cmd.String("password", "", "Password of the paket, instead of -k. Only for self-describing pakets, the salt is read from the header.")
+	cmd.Parse(args)
+	if *dir == "" {
+		fmt.Println("\"-f\" parameter cannot be null.\nSee", os.Args[0], "check-source -help")
+		os.Exit(1)
+	}
+
+	useKey := []byte(*key)
+	if *password != "" {
+		if *key != "" || *index != "" {
+			fmt.Println("\"-password\" cannot be used with \"-k\" or \"-index\".")
+			os.Exit(1)
+		}
+		f, err := os.Open(*paketName)
+		errHandler(err)
+		h, _, err := paket.ReadHeader(f)
+		f.Close()
+		errHandler(err)
+		useKey, err = paket.DeriveKey([]byte(*password), h.Salt, 32)
+		errHandler(err)
+	}
+	if err := paket.ValidKeyLength(useKey); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var p *paket.Paket
+	if *index != "" {
+		table, err := paket.LoadTable(*index)
+		errHandler(err)
+		p, err = paket.New(useKey, *paketName, table)
+		errHandler(err)
+		for _, v := range table {
+			if v.EncName != "" {
+				errHandler(p.DecryptNames())
+				break
+			}
+		}
+	} else {
+		var err error
+		p, err = paket.OpenSelfDescribing(useKey, *paketName)
+		errHandler(err)
+	}
+	defer p.Close()
+
+	stale, missing, extra, err := paket.DiffAgainstDir(p, *dir)
+	errHandler(err)
+	for _, name := range stale {
+		fmt.Printf("changed: %s\n", name)
+	}
+	for _, name := range missing {
+		fmt.Printf("not in the paket: %s\n", name)
+	}
+	for _, name := range extra {
+		fmt.Printf("not in the folder: %s\n", name)
+	}
+	if len(stale)+len(missing)+len(extra) > 0 {
+		fmt.Println("The paket must be rebuilt.")
+		p.Close()
+		os.Exit(1)
+	}
+	fmt.Println("The paket is up to date.")
 }
 
 func errHandler(err error) {
@@ -118,15 +515,47 @@ import (
 var PaketData = map[string]paket.Values{
 `
 
-var goTemplate string = `	"%s" : {StartPos : %s, EndPos : %s, OriginalLenght : %s, EncryptLenght : %s, HashOriginal : "%s", HashEncrypt : "%s"},
+var goTemplate string = `	"%s" : {StartPos : %s, EndPos : %s, OriginalLenght : %s, EncryptLenght : %s, HashOriginal : "%s", HashEncrypt : "%s"%s},
 `
 
-func confirmatorLen(l int) bool {
-	if l == 16 || l == 24 || l == 32 {
-		return true
-	}
-	return false
-}
+// written after the table.
+var macTemplate string = `
+
+// MAC of the table. Check it with Paket.VerifyTableMAC(PaketTableMAC).
+var PaketTableMAC = %#v
+`
+
+// written after the table.
+var keyCheckTemplate string = `
+
+// Key check value. Set KeyCheck of Paket to it and call CheckKey to detect wrong keys.
+var PaketKeyCheck = %#v
+`
+
+// written after the table.
+var readerVersionTemplate string = `
+
+// Reader version needed by the paket. Give it as MinReaderVersion of pengine.OpenOptions.
+const PaketMinReaderVersion = %d
+`
+
+// written after the table for the -password parameter.
+var saltTemplate string = `
+
+// Salt of the key. It is not secret. Create the key with pengine.DeriveKey(password, PaketSalt, 32).
+var PaketSalt = %#v
+`
+
+// written after the table for the -checksum parameter.
+var checksumTemplate string = `
+
+// sha256 of the paket file. Give it as Checksum of pengine.OpenOptions, or compare it with pengine.FileChecksum.
+var PaketChecksum = %q
+`
+
+// table line for the -m parameter. Only positions and lengths.
+var goMinimalTemplate string = `	"%s" : {StartPos : %s, EndPos : %s, OriginalLenght : %s, EncryptLenght : %s%s},
+`
 
 func init() {
 	flag.Parse()
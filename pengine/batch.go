@@ -0,0 +1,82 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// GetFiles reads several files in parallel. The parameters are the same as GetFile.
+//
+// At most runtime.NumCPU() files are read at the same time.
+// Returns a map of file name to content. Names given more than once are read once.
+//
+// It stops on the first error and returns it with the name of the file that caused it.
+// If shaControl is true, a hash mismatch is also an error (wrapping ErrIntegrity). Files without hashes (tables created with -m) are not checked.
+func (p *Paket) GetFiles(names []string, decrypt, shaControl bool) (map[string][]byte, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan string)
+	var (
+		mut      sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	result := make(map[string][]byte, len(names))
+
+	workers := runtime.NumCPU()
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				res, err := p.getFile(ctx, name, decrypt, shaControl)
+				// files without hashes (minimal tables) are not checked, like GetFileCheck.
+				if err == nil && res.HashChecked && !res.HashOK {
+					err = ErrIntegrity
+				}
+				mut.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("GetFiles %s: %w", name, err)
+						cancel()
+					}
+				} else {
+					result[name] = res.Data
+				}
+				mut.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool, len(names))
+feed:
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		select {
+		case jobs <- name:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
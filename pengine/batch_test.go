@@ -0,0 +1,76 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestGetFiles(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{Compression: CompressionGzip})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	names := append(p.Keys(), "a.txt")
+	got, err := p.GetFiles(names, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(files) {
+		t.Errorf("GetFiles returned %d files, want %d", len(got), len(files))
+	}
+	for name, want := range files {
+		if !bytes.Equal(got[name], want) {
+			t.Errorf("GetFiles %s: content is not the original file", name)
+		}
+	}
+
+	if _, err := p.GetFiles([]string{"a.txt", "missing"}, true, true); !errors.Is(err, ErrFileNotInTable) {
+		t.Errorf("GetFiles with a missing file: %v, want ErrFileNotInTable", err)
+	}
+}
+
+// Minimal tables have no hashes, GetFiles must not report their files as modified.
+func TestGetFilesMinimal(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{Minimal: true})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	got, err := p.GetFiles(p.Keys(), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range files {
+		if !bytes.Equal(got[name], want) {
+			t.Errorf("GetFiles %s: content is not the original file", name)
+		}
+	}
+}
+
+func TestGetFilesModified(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	v := table["a.txt"]
+	v.HashOriginal = v.HashEncrypt
+	table["a.txt"] = v
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.GetFiles(p.Keys(), true, true); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("GetFiles with a wrong hash: %v, want ErrIntegrity", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// NewRemoteCached creates a new Paket that reads from a remote reader (HTTP, S3...) and caches the decrypted files in cacheDir.
+//
+// GetFile with decrypt first looks at the cache. On a miss, the file is read from remote, decrypted and written to the cache.
+// Cached files are named by the hash of the file name and HashOriginal, and their hash is checked on every read.
+// So a stale or corrupted cache file is not served, it is read from remote again.
+// Files without HashOriginal in the table are never cached.
+//
+// A file is only cached after its MAC (if the table has one, see EntryMAC) and its hash are verified.
+// Cache hits are checked with the hash only, the MAC is not checked again.
+//
+// Warning: the cache contains the decrypted data. Keep cacheDir somewhere only your program can read.
+//
+// cacheDir is created if it does not exist.
+func NewRemoteCached(remote io.ReaderAt, cacheDir string, key []byte, table Datas) (*Paket, error) {
+	if cacheDir == "" {
+		return nil, errors.New("cacheDir cannot be empty")
+	}
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return nil, err
+	}
+	p, err := NewFromReaderAt(key, remote, table)
+	if err != nil {
+		return nil, err
+	}
+	p.cacheDir = cacheDir
+	return p, nil
+}
+
+// cachePath returns the path of the cached file. Empty if the file can't be cached.
+func (p *Paket) cachePath(filename string, file Values) string {
+	if file.HashOriginal == "" {
+		return ""
+	}
+	return filepath.Join(p.cacheDir, fmt.Sprintf("%x-%s", sha256.Sum256([]byte(filename)), file.HashOriginal))
+}
+
+// readCache returns the cached content of the file. ok is false for misses and for files whose hash doesn't match.
+func (p *Paket) readCache(filename string, file Values) (data []byte, ok bool) {
+	path := p.cachePath(filename, file)
+	if path == "" {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if !HashEqual(p.hash(file, data), file.HashOriginal) {
+		// corrupted or stale. It is overwritten by writeCache after the remote read.
+		return nil, false
+	}
+	return data, true
+}
+
+// writeCache writes the decrypted content to the cache, if its hash is correct.
+// The cache is only an optimization, so errors are ignored.
+func (p *Paket) writeCache(filename string, file Values, data []byte) {
+	path := p.cachePath(filename, file)
+	if path == "" || !HashEqual(p.hash(file, data), file.HashOriginal) {
+		return
+	}
+	// written to a temporary file first, so other readers never see a half-written file.
+	tmp, err := ioutil.TempFile(p.cacheDir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
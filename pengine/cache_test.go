@@ -0,0 +1,73 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// cachedPaket opens the paket at path with NewRemoteCached and returns it with the cache folder.
+func cachedPaket(t *testing.T, path string, table Datas) (*Paket, string) {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := t.TempDir()
+	p, err := NewRemoteCached(bytes.NewReader(data), cacheDir, testKey, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, cacheDir
+}
+
+func cacheCount(t *testing.T, cacheDir string) int {
+	t.Helper()
+	entries, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestRemoteCached(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{MAC: true})
+	p, cacheDir := cachedPaket(t, path, table)
+	checkFiles(t, p, files)
+	if cacheCount(t, cacheDir) != len(files) {
+		t.Errorf("cached files: %d, want %d", cacheCount(t, cacheDir), len(files))
+	}
+	// served from the cache.
+	checkFiles(t, p, files)
+
+	p.Close()
+	if _, _, err := p.GetFile("a.txt", true, true); err != ErrClosed {
+		t.Errorf("GetFile of a cached file after Close: %v, want ErrClosed", err)
+	}
+}
+
+// A file whose MAC doesn't match is not cached, even if it is read without checks.
+func TestRemoteCachedBadMAC(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{MAC: true})
+	v := table["a.txt"]
+	v.MAC = EntryMAC(testKey, []byte("other data"))
+	table["a.txt"] = v
+	p, cacheDir := cachedPaket(t, path, table)
+	defer p.Close()
+
+	if _, _, err := p.GetFile("a.txt", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if cacheCount(t, cacheDir) != 0 {
+		t.Error("file with a wrong MAC is cached")
+	}
+	if _, _, err := p.GetFile("a.txt", true, true); err == nil {
+		t.Error("GetFile with a wrong MAC succeeded")
+	}
+}
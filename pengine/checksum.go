@@ -0,0 +1,31 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// FileChecksum returns the hex encoded sha256 of the whole file. The file is read in chunks, it is not loaded into memory.
+//
+// Use it to check that a downloaded paket is complete and not corrupted, with one value instead of the hashes of every file.
+// The cmd tool writes it with the -checksum parameter (PaketChecksum of the table file, and a name.sha256 file).
+// The output is the same as the sha256sum tool.
+func FileChecksum(paketFileName string) (string, error) {
+	f, err := os.Open(paketFileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
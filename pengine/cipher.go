@@ -0,0 +1,56 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+// Cipher encrypts and decrypts the data of the files. Set it as Cipher of Paket (and PackOptions) to use another
+// algorithm than AES, e.g. ChaCha20-Poly1305 from golang.org/x/crypto.
+//
+// Encrypt must return everything Decrypt needs (IV, nonce, tag) in the result.
+// Both functions must be safe for concurrent use, because files are read and packed in parallel.
+type Cipher interface {
+	Encrypt(key, data []byte) ([]byte, error)
+	Decrypt(key, data []byte) ([]byte, error)
+}
+
+// AESCFB is the Cipher of ModeCFB. It uses Encrypt and Decrypt.
+type AESCFB struct{}
+
+func (AESCFB) Encrypt(key, data []byte) ([]byte, error) { return Encrypt(key, data) }
+
+func (AESCFB) Decrypt(key, data []byte) ([]byte, error) { return Decrypt(key, data) }
+
+// AESGCM is the Cipher of ModeGCM. It uses EncryptGCM and DecryptGCM.
+type AESGCM struct{}
+
+func (AESGCM) Encrypt(key, data []byte) ([]byte, error) { return EncryptGCM(key, data) }
+
+func (AESGCM) Decrypt(key, data []byte) ([]byte, error) { return DecryptGCM(key, data) }
+
+// Cipher returns the Cipher of the mode. Unknown modes return nil.
+func (m Mode) Cipher() Cipher {
+	switch m {
+	case ModeCFB:
+		return AESCFB{}
+	case ModeGCM:
+		return AESGCM{}
+	}
+	return nil
+}
+
+// cipher returns the Cipher of the Paket: Cipher if it is set, the Cipher of Mode otherwise.
+func (p *Paket) cipher() Cipher {
+	if p.Cipher != nil {
+		return p.Cipher
+	}
+	return p.Mode.Cipher()
+}
+
+// isCFB tells whether the files are encrypted with AES CFB.
+// Only CFB can be decrypted while streaming and from the middle of a file, other ciphers are decrypted completely.
+func (p *Paket) isCFB() bool {
+	_, ok := p.cipher().(AESCFB)
+	return ok
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"fmt"
+	"os"
+)
+
+// Compact copies the files in keep from the paket src to a new paket dst and returns the table of dst.
+// Files that are not in keep are dropped, so dst is smaller.
+//
+// The encrypted data is copied as it is, nothing is decrypted or encrypted again. The files are written in the order of keep
+// and the positions are recalculated. Other fields of the table are not changed.
+//
+// The encrypted data of every file is checked with HashEncrypt before copying, a corrupt file returns an error wrapping ErrIntegrity.
+// Files without HashEncrypt (tables created with -m) are copied without a check.
+//
+// dst must not exist. It is removed if Compact fails.
+func Compact(src, dst string, key []byte, table Datas, keep []string) (Datas, error) {
+	p, err := New(key, src, table)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+	newTable, err := p.compactTo(out, keep)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return nil, err
+	}
+	return newTable, nil
+}
+
+// compactTo writes the encrypted data of the files in keep to out. See Compact.
+func (p *Paket) compactTo(out *os.File, keep []string) (Datas, error) {
+	newTable := make(Datas, len(keep))
+	var pos int64
+	for _, name := range keep {
+		if _, found := newTable[name]; found {
+			continue
+		}
+		file, found := p.table()[name]
+		if !found {
+			return nil, notInTable(name)
+		}
+		content, ok, err := p.GetFile(name, false, true)
+		if err != nil {
+			return nil, err
+		}
+		if file.HashEncrypt != "" && !ok {
+			return nil, fmt.Errorf("%w: encrypted data of %s doesn't match its hash", ErrIntegrity, name)
+		}
+		if _, err := out.Write(content); err != nil {
+			return nil, err
+		}
+		file.StartPos = pos
+		pos += int64(len(content))
+		file.EndPos = pos
+		newTable[name] = file
+	}
+	return newTable, nil
+}
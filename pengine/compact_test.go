@@ -0,0 +1,91 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// flipByte changes a byte of the paket file at pos.
+func flipByte(t *testing.T, path string, pos int64) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 1
+	if _, err := f.WriteAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{Compression: CompressionGzip})
+	dst := filepath.Join(t.TempDir(), "compact.pack")
+	keep := []string{"license", "c.txt", "license"}
+	newTable, err := Compact(path, dst, testKey, table, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newTable) != 2 {
+		t.Fatalf("%d files in the new table, want 2", len(newTable))
+	}
+	// the files are written in the order of keep.
+	if newTable["license"].StartPos != 0 || newTable["c.txt"].StartPos != newTable["license"].EndPos {
+		t.Errorf("positions are not in the order of keep: %+v", newTable)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != newTable["c.txt"].EndPos {
+		t.Errorf("compacted paket is %d bytes, want %d", info.Size(), newTable["c.txt"].EndPos)
+	}
+	p, err := New(testKey, dst, newTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, map[string][]byte{"license": files["license"], "c.txt": files["c.txt"]})
+}
+
+func TestCompactErrors(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "compact.pack")
+	if _, err := Compact(path, dst, testKey, table, []string{"a.txt", "missing"}); !errors.Is(err, ErrFileNotInTable) {
+		t.Errorf("Compact with a missing file: %v, want ErrFileNotInTable", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("dst is not removed after a failed Compact: %v", err)
+	}
+
+	flipByte(t, path, table["a.txt"].StartPos+IVSize)
+	if _, err := Compact(path, dst, testKey, table, []string{"c.txt", "a.txt"}); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("Compact of a modified file: %v, want ErrIntegrity", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("dst is not removed after a failed Compact: %v", err)
+	}
+
+	// dst is not overwritten.
+	if err := ioutil.WriteFile(dst, []byte("user file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Compact(path, dst, testKey, table, []string{"c.txt"}); !errors.Is(err, os.ErrExist) {
+		t.Errorf("Compact to an existing file: %v, want os.ErrExist", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/bits"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression algorithm of a file in the paket. It is recorded per file, so a paket can mix them.
+type Compression uint8
+
+const (
+	// CompressionNone means the file is not compressed.
+	CompressionNone Compression = iota
+
+	// CompressionGzip is gzip (see Compress). Old tables have Compressed: true for it.
+	CompressionGzip
+
+	// CompressionZstd is zstandard. It compresses better and decompresses faster than gzip.
+	CompressionZstd
+)
+
+// DecompressLimit returns this error if the decompressed data is larger than the limit.
+var ErrDecompressedTooLarge = errors.New("decompressed data is larger than the limit")
+
+// CompressionByName returns the compression of the name: "none" (or empty), "gzip" or "zstd".
+func CompressionByName(name string) (Compression, error) {
+	switch name {
+	case "", "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zstd":
+		return CompressionZstd, nil
+	}
+	return CompressionNone, fmt.Errorf("unknown compression: %s", name)
+}
+
+// String returns the name of the compression, as accepted by CompressionByName.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", uint8(c))
+}
+
+// MarshalJSON writes the compression as its name, like "zstd". See WriteTableJSON.
+func (c Compression) MarshalJSON() ([]byte, error) {
+	if c > CompressionZstd {
+		return nil, fmt.Errorf("unknown compression: %d", uint8(c))
+	}
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON reads the name of the compression (see CompressionByName).
+func (c *Compression) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	compression, err := CompressionByName(name)
+	if err != nil {
+		return err
+	}
+	*c = compression
+	return nil
+}
+
+// Compress compresses the data with gzip. The cmd tool uses it before encryption (-c parameter).
+//
+// Already compressed data (audio, images, archives) can get bigger. Compare the lengths and store the smaller one.
+func Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses the data compressed by Compress. The output is not limited, see DecompressLimit for untrusted data.
+func Decompress(data []byte) ([]byte, error) {
+	return DecompressLimit(CompressionGzip, data, 0)
+}
+
+// CompressWith compresses the data with c. CompressionNone returns data as it is.
+func CompressWith(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		return Compress(data)
+	case CompressionZstd:
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, err
+		}
+		return enc.EncodeAll(data, nil), nil
+	}
+	return nil, fmt.Errorf("unknown compression: %s", c)
+}
+
+// DecompressWith decompresses the data compressed by CompressWith. The output is not limited, see DecompressLimit for untrusted data.
+func DecompressWith(c Compression, data []byte) ([]byte, error) {
+	return DecompressLimit(c, data, 0)
+}
+
+// DecompressLimit decompresses the data compressed by CompressWith, like DecompressWith.
+// If the output is larger than limit bytes, it stops and returns an error wrapping ErrDecompressedTooLarge,
+// so a small crafted input can't fill the memory. 0 means no limit. GetFile uses OriginalLenght of the file as the limit.
+func DecompressLimit(c Compression, data []byte, limit int64) ([]byte, error) {
+	var out []byte
+	var err error
+	switch c {
+	case CompressionNone:
+		out = data
+	case CompressionGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		var lr io.Reader = r
+		if limit > 0 {
+			// one more byte to see if it is larger.
+			lr = io.LimitReader(r, limit+1)
+		}
+		out, err = ioutil.ReadAll(lr)
+	case CompressionZstd:
+		var dec *zstd.Decoder
+		if dec, err = zstdDecoder(limit); err != nil {
+			return nil, err
+		}
+		out, err = dec.DecodeAll(data, nil)
+		// the window of the decoder is also limited, a frame with a larger window is too large too.
+		if err == zstd.ErrDecoderSizeExceeded || err == zstd.ErrWindowSizeExceeded || err == zstd.ErrFrameSizeExceeded {
+			return nil, fmt.Errorf("%w: %d bytes", ErrDecompressedTooLarge, limit)
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression: %s", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrDecompressedTooLarge, limit)
+	}
+	return out, nil
+}
+
+var (
+	zstdEncOnce sync.Once
+	zstdEnc     *zstd.Encoder
+	zstdEncErr  error
+
+	// zstdDecs are the decoders by their limit, see zstdDecoder. Protected by zstdDecMut.
+	zstdDecs   = make(map[int]*zstd.Decoder)
+	zstdDecMut sync.Mutex
+)
+
+// zstdEncoder returns the encoder of CompressWith. It is created once, EncodeAll can be called concurrently.
+func zstdEncoder() (*zstd.Encoder, error) {
+	zstdEncOnce.Do(func() {
+		zstdEnc, zstdEncErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	})
+	return zstdEnc, zstdEncErr
+}
+
+// zstdDecoder returns a decoder that stops after limit bytes (0 means no limit), for DecompressLimit.
+// The limit of zstd decoders is fixed when they are created, so there is one decoder for each power of two
+// and the limit is rounded up to the next one (the window of a frame can be a bit larger than its content).
+// So at most twice the limit is decoded, DecompressLimit checks the exact limit after decoding.
+// Decoders are created once and never closed, DecodeAll can be called concurrently.
+func zstdDecoder(limit int64) (*zstd.Decoder, error) {
+	// 1 MB is the smallest limit, decoders for smaller ones don't save memory.
+	size := 63
+	if limit > 0 {
+		size = bits.Len64(uint64(limit))
+		if size < 20 {
+			size = 20
+		}
+	}
+	zstdDecMut.Lock()
+	defer zstdDecMut.Unlock()
+	if dec, found := zstdDecs[size]; found {
+		return dec, nil
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(1<<uint(size)))
+	if err != nil {
+		return nil, err
+	}
+	zstdDecs[size] = dec
+	return dec, nil
+}
+
+// decompressReader returns a reader that decompresses r with c.
+func decompressReader(c Compression, r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case CompressionNone:
+		return ioutil.NopCloser(r), nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unknown compression: %s", c)
+}
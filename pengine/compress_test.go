@@ -0,0 +1,75 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, size := range []int{1000, 1<<20 + 1, 3 << 20} {
+		compressRoundTrip(t, bytes.Repeat([]byte("paket compresses text well. "), size/28+1))
+	}
+	// the limits of the zstd decoders are powers of two.
+	compressRoundTrip(t, make([]byte, 1<<20))
+}
+
+func compressRoundTrip(t *testing.T, data []byte) {
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		cdata, err := CompressWith(c, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != CompressionNone && len(cdata) >= len(data) {
+			t.Errorf("%s: compressed %d bytes to %d", c, len(data), len(cdata))
+		}
+		got, err := DecompressWith(c, cdata)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: DecompressWith: %d bytes, %v", c, len(got), err)
+		}
+		got, err = DecompressLimit(c, cdata, int64(len(data)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: DecompressLimit with the exact length: %d bytes, %v", c, len(got), err)
+		}
+	}
+}
+
+// A small input that decompresses to much more than the limit must fail without decompressing all of it.
+func TestDecompressLimit(t *testing.T) {
+	bomb := make([]byte, 8<<20)
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		cdata, err := CompressWith(c, bomb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, limit := range []int64{100, 2 << 20, int64(len(bomb)) - 1} {
+			if _, err := DecompressLimit(c, cdata, limit); !errors.Is(err, ErrDecompressedTooLarge) {
+				t.Errorf("%s with limit %d: %v, want ErrDecompressedTooLarge", c, limit, err)
+			}
+		}
+	}
+}
+
+// GetFile limits the decompression to OriginalLenght of the table.
+func TestGetFileDecompressLimit(t *testing.T) {
+	files := map[string][]byte{"big.txt": bytes.Repeat([]byte("a"), 1<<20)}
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		path, table := packTest(t, files, PackOptions{Compression: c, Minimal: true})
+		v := table["big.txt"]
+		v.OriginalLenght = 1000
+		table["big.txt"] = v
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := p.GetFile("big.txt", true, false); !errors.Is(err, ErrLengthMismatch) {
+			t.Errorf("%s: GetFile of a file larger than the table: %v, want ErrLengthMismatch", c, err)
+		}
+		p.Close()
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+)
+
+// crcTable is the Castagnoli table of the CRCs in the table. It is fast on modern CPUs.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC calculates the CRC32 (Castagnoli) of the encrypted data, like the CRC field of Values.
+func CRC(encData []byte) uint32 {
+	return crc32.Checksum(encData, crcTable)
+}
+
+// CheckLevel is the check done by GetFileCheck.
+type CheckLevel int
+
+const (
+	// CheckNone does no check. Same as GetFile with shaControl false.
+	CheckNone CheckLevel = iota
+
+	// CheckCRC compares the CRC of the encrypted data. It is much faster than the hash and finds corrupt data,
+	// but not modified data. Files without a CRC are checked with the hash.
+	CheckCRC
+
+	// CheckSHA compares the hash (or the MAC), like GetFile with shaControl true.
+	CheckSHA
+)
+
+// GetFileCheck returns the content of the file like GetFile, but the check is selected with level.
+// A failed check returns an error wrapping ErrIntegrity.
+//
+// Files without the needed CRC and hash in the table (tables created with -m) are returned without a check.
+func (p *Paket) GetFileCheck(filename string, decrypt bool, level CheckLevel) ([]byte, error) {
+	file, found := p.table()[filename]
+	if !found {
+		return nil, notInTable(filename)
+	}
+	if level == CheckCRC && file.CRC == 0 {
+		level = CheckSHA
+	}
+
+	switch level {
+	case CheckNone:
+		data, _, err := p.GetFile(filename, decrypt, false)
+		return data, err
+	case CheckCRC:
+		res, err := p.getFile(context.Background(), filename, false, false)
+		if err != nil {
+			return nil, err
+		}
+		if CRC(res.Data) != file.CRC {
+			return nil, fmt.Errorf("%w: CRC of %s doesn't match", ErrIntegrity, filename)
+		}
+		if !decrypt {
+			return res.Data, nil
+		}
+		return p.decrypt(filename, file, res.Data)
+	case CheckSHA:
+		res, err := p.getFile(context.Background(), filename, decrypt, true)
+		if err != nil {
+			return nil, err
+		}
+		if res.HashChecked && !res.HashOK {
+			return nil, fmt.Errorf("%w: hash of %s doesn't match", ErrIntegrity, filename)
+		}
+		return res.Data, nil
+	}
+	return nil, fmt.Errorf("unknown check level %d", level)
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
+	"sync"
+)
+
+// Crypter encrypts and decrypts with a fixed key. The AES key schedule is calculated once in NewCrypter,
+// Encrypt and the other functions calculate it for every call. Use it to encrypt or decrypt many files with the same key.
+//
+// The output is the same as the functions with the same names (Encrypt, EncryptGCM...), they can be mixed.
+// A Crypter is safe for concurrent use.
+type Crypter struct {
+	block cipher.Block
+
+	// created on the first GCM call, CFB users don't need it.
+	gcmOnce sync.Once
+	gcm     cipher.AEAD
+	gcmErr  error
+}
+
+// NewCrypter creates a Crypter for the key. Key must be 16, 24 or 32 size.
+func NewCrypter(key []byte) (*Crypter, error) {
+	if err := ValidKeyLength(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Crypter{block: block}, nil
+}
+
+// Encrypt encrypts the data in CFB mode with a random IV, like the Encrypt function.
+func (c *Crypter) Encrypt(data []byte) ([]byte, error) {
+	ciphertext := make([]byte, IVSize+len(data))
+	iv := ciphertext[:IVSize]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	cipher.NewCFBEncrypter(c.block, iv).XORKeyStream(ciphertext[IVSize:], data)
+	return ciphertext, nil
+}
+
+// Decrypt decrypts the data encrypted in CFB mode, like the Decrypt function.
+// Returns ErrShortCiphertext if data is shorter than IVSize.
+func (c *Crypter) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < IVSize {
+		return nil, ErrShortCiphertext
+	}
+	out := make([]byte, len(data)-IVSize)
+	cipher.NewCFBDecrypter(c.block, data[:IVSize]).XORKeyStream(out, data[IVSize:])
+	return out, nil
+}
+
+// EncryptGCM encrypts the data in GCM mode with a random nonce, like EncryptGCMAAD. aad can be nil.
+func (c *Crypter) EncryptGCM(data, aad []byte) ([]byte, error) {
+	gcm, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, aad), nil
+}
+
+// DecryptGCM decrypts the data encrypted in GCM mode, like DecryptGCMAAD. aad must be the same as in EncryptGCM.
+// Returns ErrIntegrity if the key is wrong, the data was modified or aad is not the same.
+func (c *Crypter) DecryptGCM(data, aad []byte) ([]byte, error) {
+	gcm, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrIntegrity
+	}
+	nonce := data[:gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, data[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrIntegrity
+	}
+	return plain, nil
+}
+
+// aead returns the GCM of the block, it is created on the first call.
+func (c *Crypter) aead() (cipher.AEAD, error) {
+	c.gcmOnce.Do(func() {
+		c.gcm, c.gcmErr = cipher.NewGCM(c.block)
+	})
+	return c.gcm, c.gcmErr
+}
+
+// keyCrypter returns the Crypter of Key. It is created once and created again if Key is changed.
+func (p *Paket) keyCrypter() (*Crypter, error) {
+	p.crypterMut.Lock()
+	defer p.crypterMut.Unlock()
+	if p.crypter != nil && subtle.ConstantTimeCompare(p.crypterKey, p.Key) == 1 {
+		return p.crypter, nil
+	}
+	c, err := NewCrypter(p.Key)
+	if err != nil {
+		return nil, err
+	}
+	WipeKey(p.crypterKey)
+	p.crypter, p.crypterKey = c, append([]byte(nil), p.Key...)
+	return c, nil
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// SaltSize is the length of the salt created by CreateSalt.
+const SaltSize = 16
+
+// scrypt parameters of DeriveKey. Recommended values for interactive logins (2017).
+// Changing them changes the derived keys, so old pakets can't be read anymore.
+const (
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+)
+
+// CreateSalt generates a random salt for DeriveKey.
+//
+// The salt is not secret. It is written to the table file by the cmd tool (-password parameter).
+func CreateSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// DeriveKey derives an encryption key from a password with scrypt.
+//
+// The same password and salt always give the same key. Use the salt the paket was created with.
+//
+// keyLen must be 16, 24 or 32.
+func DeriveKey(password, salt []byte, keyLen int) ([]byte, error) {
+	if err := validKeySize(keyLen); err != nil {
+		return nil, err
+	}
+	if len(salt) == 0 {
+		return nil, errors.New("salt cannot be empty")
+	}
+	return scrypt.Key(password, salt, scryptN, scryptR, scryptP, keyLen)
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// syntheticIV returns an IV (or nonce) of size bytes derived from the key, aad and the data.
+// The HMAC key is derived from key (see subKey), the AES key is not used for the HMAC.
+// The same key, aad and data always give the same IV, different ones give a different IV.
+// aad is nil if there is no additional data.
+func syntheticIV(key, aad, data []byte, size int) []byte {
+	m := hmac.New(sha256.New, subKey(key, "paket synthetic iv"))
+	if aad != nil {
+		// length-prefixed, so the border of aad and data can't be moved.
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(aad)))
+		m.Write(l[:])
+		m.Write(aad)
+	}
+	m.Write(data)
+	return m.Sum(nil)[:size]
+}
+
+// EncryptDeterministic encrypts the data like Encrypt, but the IV is derived from the key and the data
+// (an HMAC-SHA256 of the data) instead of being random. The output can be decrypted with Decrypt.
+//
+// The same key and data always give the same bytes, so packing is reproducible (see PackOptions.Deterministic).
+//
+// Warning: someone who sees two encrypted files can tell whether their contents are the same.
+// Use Encrypt if this must not be known.
+func EncryptDeterministic(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, IVSize+len(data))
+	iv := ciphertext[:IVSize]
+	copy(iv, syntheticIV(key, nil, data, IVSize))
+
+	s := cipher.NewCFBEncrypter(block, iv)
+	s.XORKeyStream(ciphertext[IVSize:], data)
+	return ciphertext, nil
+}
+
+// EncryptGCMDeterministic encrypts the data like EncryptGCM, but the nonce is derived from the key and the data.
+// The output can be decrypted with DecryptGCM. See EncryptDeterministic for the same warning.
+func EncryptGCMDeterministic(key, data []byte) ([]byte, error) {
+	return encryptGCMDeterministicAAD(key, data, nil)
+}
+
+// encryptGCMDeterministicAAD is EncryptGCMDeterministic with additional data (see EncryptGCMAAD).
+// The nonce depends on aad too, a nonce must never be used again with other aad.
+func encryptGCMDeterministicAAD(key, data, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+	copy(nonce, syntheticIV(key, aad, data, gcm.NonceSize()))
+	return gcm.Seal(nonce, nonce, data, aad), nil
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDeterministic(t *testing.T) {
+	data := []byte("same data, same bytes")
+	for _, c := range []struct {
+		name    string
+		encrypt func(key, data []byte) ([]byte, error)
+		decrypt func(key, data []byte) ([]byte, error)
+	}{
+		{"CFB", EncryptDeterministic, Decrypt},
+		{"GCM", EncryptGCMDeterministic, DecryptGCM},
+	} {
+		first, err := c.encrypt(testKey, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := c.encrypt(testKey, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("%s: the same data is encrypted differently", c.name)
+		}
+		if other, _ := c.encrypt(testKey, []byte("other data, other bytes")); bytes.Equal(first[:IVSize], other[:IVSize]) {
+			t.Errorf("%s: other data has the same IV", c.name)
+		}
+		otherKey := append([]byte(nil), testKey...)
+		otherKey[0] ^= 1
+		if other, _ := c.encrypt(otherKey, data); bytes.Equal(first, other) {
+			t.Errorf("%s: another key gives the same bytes", c.name)
+		}
+		if got, err := c.decrypt(testKey, first); err != nil || !bytes.Equal(got, data) {
+			t.Errorf("%s: decrypting: %v", c.name, err)
+		}
+	}
+}
+
+// The same files give the same paket, also when they are written at another time.
+func TestPackDeterministic(t *testing.T) {
+	for _, opts := range []PackOptions{
+		{Deterministic: true},
+		{Deterministic: true, Mode: ModeGCM, Compression: CompressionGzip},
+		{Deterministic: true, Mode: ModeGCM, BindNames: true, MAC: true},
+	} {
+		files := testFiles()
+		path1, table1 := packTest(t, files, opts)
+		path2, table2 := packTest(t, files, opts)
+		data1, err := ioutil.ReadFile(path1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data2, err := ioutil.ReadFile(path2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data1, data2) {
+			t.Errorf("%+v: pakets of the same files are different", opts)
+		}
+		for name, v := range table1 {
+			v2 := table2[name]
+			v.ModTime, v2.ModTime = 0, 0
+			if v != v2 {
+				t.Errorf("%+v: %s: %+v, %+v", opts, name, v, v2)
+			}
+		}
+
+		p, err := New(testKey, path1, table1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		checkFiles(t, p, files)
+		p.Close()
+	}
+}
+
+func TestPackDeterministicStreamed(t *testing.T) {
+	_, paths := writeTestFiles(t, testFiles())
+	f, err := os.Create(filepath.Join(t.TempDir(), "data.pack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Pack(f, testKey, paths, PackOptions{Deterministic: true, StreamSize: 100}); err == nil {
+		t.Error("Pack with Deterministic and StreamSize succeeded")
+	}
+}
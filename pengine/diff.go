@@ -0,0 +1,62 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// DiffAgainstDir compares the Paket with the folder it was created from.
+// Like the cmd tool, only the files directly in dir are used, subfolders are skipped.
+//
+// The hash of every file in dir is compared with HashOriginal in the table (with the algorithm of the table, see HashAlgorithm).
+//
+// stale: files in both, but changed after packing.
+// missing: files in dir but not in the Paket.
+// extra: files in the Paket but not in dir.
+//
+// All lists are sorted. If all of them are empty, the Paket doesn't need to be rebuilt.
+// The check-source command of the cmd tool prints them.
+func DiffAgainstDir(p *Paket, dir string) (stale, missing, extra []string, err error) {
+	listFiles, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	inDir := make(map[string]bool, len(listFiles))
+	for _, file := range listFiles {
+		if file.IsDir() {
+			continue
+		}
+		name := file.Name()
+		inDir[name] = true
+		value, found := p.table()[name]
+		if !found {
+			missing = append(missing, name)
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !HashEqual(p.hash(value, content), value.HashOriginal) {
+			stale = append(stale, name)
+		}
+	}
+
+	for name := range p.table() {
+		if !inDir[name] {
+			extra = append(extra, name)
+		}
+	}
+
+	sort.Strings(stale)
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return stale, missing, extra, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffAgainstDir(t *testing.T) {
+	files := testFiles()
+	dir, paths := writeTestFiles(t, files)
+	out := filepath.Join(t.TempDir(), "data.pack")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := Pack(f, testKey, paths, PackOptions{})
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(testKey, out, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	stale, missing, extra, err := DiffAgainstDir(p, dir)
+	if err != nil || len(stale)+len(missing)+len(extra) != 0 {
+		t.Fatalf("DiffAgainstDir of the source folder: %v %v %v %v", stale, missing, extra, err)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte("changed"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0600)
+	os.Remove(filepath.Join(dir, "b.bin"))
+	os.Remove(filepath.Join(dir, "a.txt"))
+	stale, missing, extra, err = DiffAgainstDir(p, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stale, []string{"c.txt"}) || !reflect.DeepEqual(missing, []string{"new.txt"}) || !reflect.DeepEqual(extra, []string{"a.txt", "b.bin"}) {
+		t.Errorf("DiffAgainstDir: stale %v, missing %v, extra %v", stale, missing, extra)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// subKey derives a 32 byte key for one purpose from key, with HKDF-SHA256 and the purpose as info.
+// The key of the paket is the AES key of the files, it is never used directly for anything else:
+// the MACs, the key check value, the synthetic IVs, the name IDs and the metadata blobs have their own keys.
+// So a value calculated for one purpose can't be used for another one.
+func subKey(key []byte, purpose string) []byte {
+	sk := make([]byte, 32)
+	// HKDF-SHA256 can give 8160 bytes, reading 32 never fails.
+	io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(purpose)), sk)
+	return sk
+}
+
+// EntryKey derives the key of a file from the master key and the name of the file, with HKDF-SHA256.
+// The derived key has the same length as masterKey.
+//
+// Pakets created with PerEntryKeys (PackOptions, or -entrykeys of the cmd tool) encrypt every file with its own key.
+// Someone who gets the key of one file can't decrypt the others, the master key is needed for them.
+//
+// The same master key and name always give the same key, so readers derive it again. Renaming a file in the table breaks it.
+func EntryKey(masterKey []byte, name string) ([]byte, error) {
+	key := make([]byte, len(masterKey))
+	r := hkdf.New(sha256.New, masterKey, nil, []byte("paket entry key: "+name))
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// fileKey returns the key that decrypts the file: Key, or the key derived by EntryKey if PerEntryKeys is true.
+func (p *Paket) fileKey(filename string) ([]byte, error) {
+	if !p.PerEntryKeys {
+		return p.Key, nil
+	}
+	return EntryKey(p.Key, filename)
+}
+
+// fileBlock returns the AES block of the key of the file (see fileKey). Used by the CFB fast paths.
+func (p *Paket) fileBlock(filename string) (cipher.Block, error) {
+	c, err := p.fileCrypter(filename)
+	if err != nil {
+		return nil, err
+	}
+	return c.block, nil
+}
+
+// fileCrypter returns the Crypter of the key of the file (see fileKey).
+// Without PerEntryKeys it is the cached Crypter of Key.
+func (p *Paket) fileCrypter(filename string) (*Crypter, error) {
+	if !p.PerEntryKeys {
+		return p.keyCrypter()
+	}
+	key, err := EntryKey(p.Key, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer WipeKey(key)
+	return NewCrypter(key)
+}
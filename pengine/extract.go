@@ -0,0 +1,146 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExtractOptions changes the behavior of ExtractAllContext.
+type ExtractOptions struct {
+	// If true, the files already written are removed when the context is cancelled.
+	// So a cancelled extraction doesn't leave a half-extracted folder.
+	RollbackOnCancel bool
+
+	// If true, the hash (or MAC) of every file is checked before writing it. A mismatch returns an error wrapping ErrIntegrity.
+	// Files without a hash (tables created with -m) are written without a check.
+	Verify bool
+}
+
+// ExtractAll writes the decrypted content of all files in the Paket to destDir.
+//
+// See ExtractAllContext.
+func (p *Paket) ExtractAll(destDir string) ([]string, error) {
+	return p.ExtractAllContext(context.Background(), destDir, ExtractOptions{})
+}
+
+// Unpack writes the decrypted content of all files in the Paket to destDir, checking their hashes.
+// Names with slashes are written to sub folders, names pointing outside of destDir are refused.
+//
+// It returns on the first failure, the error contains the name of the file. See ExtractAllContext.
+func (p *Paket) Unpack(destDir string) error {
+	_, err := p.ExtractAllContext(context.Background(), destDir, ExtractOptions{Verify: true})
+	return err
+}
+
+// ExtractAllContext writes the decrypted content of all files in the Paket to destDir.
+// destDir is created if it does not exist. Modification times are restored if they are in the table.
+//
+// Files are extracted in sorted order. ctx is checked between files and while reading them (see GetFileContext).
+// If ctx is cancelled, ctx.Err() is returned. If opts.RollbackOnCancel is true, the files written until then are removed,
+// and the folders created for them (also destDir) if they are empty.
+//
+// Names are checked with SanitizeName, unsafe names return an error wrapping ErrUnsafeName.
+//
+// Returns the paths of the written files, also with an error.
+// In the rollback case, these are the files that were removed.
+func (p *Paket) ExtractAllContext(ctx context.Context, destDir string, opts ExtractOptions) ([]string, error) {
+	// the names and their entries are from the same table, also if Swap runs meanwhile.
+	table := p.table()
+	names := sortedNames(table)
+
+	// folders created by the extraction, parents first. They are removed in the rollback.
+	var created []string
+	mkdir := func(dir string) error {
+		dirs, err := mkdirAll(dir)
+		created = append(created, dirs...)
+		return err
+	}
+	if err := mkdir(destDir); err != nil {
+		return nil, err
+	}
+
+	written := []string{}
+	cancelled := func(err error) ([]string, error) {
+		if opts.RollbackOnCancel {
+			for _, path := range written {
+				os.Remove(path)
+			}
+			// children first. Folders with other files are not removed.
+			for i := len(created) - 1; i >= 0; i-- {
+				os.Remove(created[i])
+			}
+		}
+		return written, err
+	}
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return cancelled(err)
+		}
+
+		clean, err := SanitizeName(name)
+		if err != nil {
+			return written, err
+		}
+		file := table[name]
+		verify := opts.Verify && (file.HashOriginal != "" || file.MAC != "")
+		content, ok, err := p.GetFileContext(ctx, name, true, verify)
+		if err != nil {
+			if err == ctx.Err() {
+				return cancelled(err)
+			}
+			return written, fmt.Errorf("%s: %w", name, err)
+		}
+		if verify && !ok {
+			return written, fmt.Errorf("%s: %w", name, ErrIntegrity)
+		}
+		path := filepath.Join(destDir, filepath.FromSlash(clean))
+		if err := mkdir(filepath.Dir(path)); err != nil {
+			return written, err
+		}
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+		if modTime := file.ModTime; modTime != 0 {
+			t := time.Unix(0, modTime)
+			if err := os.Chtimes(path, t, t); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// mkdirAll is os.MkdirAll, but it returns the folders it created, parents first.
+func mkdirAll(dir string) ([]string, error) {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			return created, err
+		}
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cancelAfter is a context that is cancelled when the file path exists, so the extraction stops after this file.
+type cancelAfter struct {
+	context.Context
+	path string
+}
+
+func (c cancelAfter) Err() error {
+	if _, err := os.Stat(c.path); err == nil {
+		return context.Canceled
+	}
+	return nil
+}
+
+// extractTest packs the test files, moves a.txt to a sub folder and opens the paket.
+func extractTest(t *testing.T) (*Paket, map[string][]byte) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	table["deep/sub/a.txt"] = table["a.txt"]
+	delete(table, "a.txt")
+	files["deep/sub/a.txt"] = files["a.txt"]
+	delete(files, "a.txt")
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, files
+}
+
+func TestExtractAll(t *testing.T) {
+	p, files := extractTest(t)
+	defer p.Close()
+	dest := filepath.Join(t.TempDir(), "out")
+	written, err := p.ExtractAll(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(files) {
+		t.Errorf("written files: %d, want %d", len(written), len(files))
+	}
+	for name, want := range files {
+		got, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("extracted %s: %v", name, err)
+		}
+	}
+}
+
+func TestExtractAllCancel(t *testing.T) {
+	p, _ := extractTest(t)
+	defer p.Close()
+	dest := filepath.Join(t.TempDir(), "out")
+	// b.bin is the first file in sorted order.
+	ctx := cancelAfter{context.Background(), filepath.Join(dest, "b.bin")}
+	written, err := p.ExtractAllContext(ctx, dest, ExtractOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExtractAllContext: %v, want context.Canceled", err)
+	}
+	if len(written) != 1 {
+		t.Fatalf("written files: %v, want only b.bin", written)
+	}
+	// without RollbackOnCancel, the written file stays.
+	if _, err := os.Stat(written[0]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExtractAllRollback(t *testing.T) {
+	p, _ := extractTest(t)
+	defer p.Close()
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "out")
+	// a file in new sub folders, in the middle of the sorted names.
+	ctx := cancelAfter{context.Background(), filepath.Join(dest, "deep", "sub", "a.txt")}
+	written, err := p.ExtractAllContext(ctx, dest, ExtractOptions{RollbackOnCancel: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExtractAllContext: %v, want context.Canceled", err)
+	}
+	if len(written) == 0 {
+		t.Fatal("no file is written before the cancel")
+	}
+	// the created folders are removed too, also dest.
+	entries, err := ioutil.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("rollback left %d entries in the parent folder", len(entries))
+	}
+
+	// folders that existed before are kept, with the files of the user.
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	keep := filepath.Join(dest, "keep.txt")
+	if err := ioutil.WriteFile(keep, []byte("user file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ExtractAllContext(ctx, dest, ExtractOptions{RollbackOnCancel: true}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ExtractAllContext: %v, want context.Canceled", err)
+	}
+	entries, err = ioutil.ReadDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "keep.txt" {
+		t.Errorf("entries after the rollback: %d, want only keep.txt", len(entries))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// File is an opened file of the Paket. It implements io.ReadSeekCloser, so it can be given to http.ServeContent:
+//
+//	f, err := p.Open("video.mp4")
+//	...
+//	defer f.Close()
+//	info, _ := f.Stat()
+//	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+//
+// It is created by Paket.Open. A File is not safe for concurrent use, open one File per goroutine.
+type File struct {
+	p    *Paket
+	name string
+	file Values
+	off  int64
+	// gen of the Paket at Open. Reads after Swap return ErrClosed.
+	gen uint64
+
+	// decrypted content, for files that can't be decrypted from the middle. nil for CFB files without compression.
+	buf    *bytes.Reader
+	closed bool
+}
+
+// Open opens the file for reading. (Not to be confused with the Open function, which opens a paket.)
+//
+// CFB and unencrypted files without compression are decrypted while they are read, only the parts read are loaded (see ReadRange).
+// Seeking is free for them. Compressed and GCM files are decrypted completely here, like GetFile.
+//
+// No hash checking is done. If the file cannot be found in the map, the error wraps ErrFileNotInTable.
+func (p *Paket) Open(filename string) (*File, error) {
+	p.globMut.RLock()
+	gen := p.gen
+	p.globMut.RUnlock()
+	file, found := p.table()[filename]
+	if !found {
+		return nil, notInTable(filename)
+	}
+	f := &File{p: p, name: filename, file: file, gen: gen}
+	if (!p.isCFB() && !file.Unencrypted) || file.compression() != CompressionNone {
+		content, _, err := p.GetFile(filename, true, false)
+		if err != nil {
+			return nil, err
+		}
+		f.buf = bytes.NewReader(content)
+		return f, nil
+	}
+
+	p.globMut.RLock()
+	closed := p.reader == nil
+	p.globMut.RUnlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	return f, nil
+}
+
+// Read implements io.Reader. Reads after the end of the file return io.EOF.
+func (f *File) Read(b []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.buf != nil {
+		return f.buf.Read(b)
+	}
+	if f.off >= f.file.OriginalLenght {
+		return 0, io.EOF
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	f.p.globMut.RLock()
+	swapped := f.p.gen != f.gen
+	f.p.globMut.RUnlock()
+	if swapped {
+		return 0, ErrClosed
+	}
+	data, err := f.p.ReadRange(f.name, f.off, int64(len(b)), true)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(b, data)
+	f.off += int64(n)
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo, so io.Copy(w, f) writes the file to w from the current position to the end.
+// CFB files without compression are decrypted from the paket straight into w, without the Read calls of io.Copy.
+// The position is moved by the number of bytes written.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.buf != nil {
+		return f.buf.WriteTo(w)
+	}
+	if f.off >= f.file.OriginalLenght {
+		return 0, nil
+	}
+	f.p.globMut.RLock()
+	swapped := f.p.gen != f.gen
+	f.p.globMut.RUnlock()
+	if swapped {
+		return 0, ErrClosed
+	}
+	_, r, err := f.p.cfbReader(f.name, f.off)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.CopyBuffer(w, r, make([]byte, f.p.bufferSize()))
+	f.off += n
+	return n, err
+}
+
+// Seek implements io.Seeker. Seeking after the end of the file is allowed, the next Read returns io.EOF.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.buf != nil {
+		return f.buf.Seek(offset, whence)
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.file.OriginalLenght
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+// Stat returns the name (without folders), size and modification time of the file.
+func (f *File) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: path.Base(slashName(f.name)), size: f.file.OriginalLenght, modTime: f.file.ModTime}, nil
+}
+
+// Close closes the File. It doesn't close the Paket. Read and Seek return fs.ErrClosed after it.
+func (f *File) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	f.buf = nil
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	for _, opts := range []PackOptions{
+		{},
+		{Mode: ModeGCM},
+		{Compression: CompressionGzip},
+	} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		for name, want := range files {
+			f, err := p.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(f)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%+v: reading %s: %v", opts, name, err)
+			}
+			info, err := f.Stat()
+			if err != nil || info.Name() != name || info.Size() != int64(len(want)) {
+				t.Errorf("%+v: Stat of %s: %v, %v", opts, name, info, err)
+			}
+			f.Close()
+		}
+
+		f, err := p.Open("a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := files["a.txt"]
+		if pos, err := f.Seek(-100, io.SeekEnd); err != nil || pos != int64(len(want))-100 {
+			t.Fatalf("%+v: Seek: %d, %v", opts, pos, err)
+		}
+		b := make([]byte, 40)
+		if _, err := io.ReadFull(f, b); err != nil || !bytes.Equal(b, want[len(want)-100:len(want)-60]) {
+			t.Errorf("%+v: Read after Seek: %v", opts, err)
+		}
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := f.Read(b); n != 0 || err != io.EOF {
+			t.Errorf("%+v: Read at the end: %d, %v, want io.EOF", opts, n, err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Read(b); !errors.Is(err, fs.ErrClosed) {
+			t.Errorf("%+v: Read after Close: %v, want fs.ErrClosed", opts, err)
+		}
+		p.Close()
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Open("missing"); !errors.Is(err, ErrFileNotInTable) {
+		t.Errorf("Open of a missing file: %v, want ErrFileNotInTable", err)
+	}
+	f, err := p.Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek to a negative position succeeded")
+	}
+	p.Close()
+	if _, err := p.Open("a.txt"); err != ErrClosed {
+		t.Errorf("Open after Close: %v, want ErrClosed", err)
+	}
+	if _, err := f.Read(make([]byte, 10)); err != ErrClosed {
+		t.Errorf("Read after Close of the paket: %v, want ErrClosed", err)
+	}
+}
+
+func TestFileWriteTo(t *testing.T) {
+	for _, opts := range []PackOptions{{}, {Compression: CompressionGzip}} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := files["a.txt"]
+		f, err := p.Open("a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Seek(10, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if n, err := io.Copy(&buf, f); err != nil || n != int64(len(want))-10 || !bytes.Equal(buf.Bytes(), want[10:]) {
+			t.Errorf("%+v: WriteTo: %d, %v", opts, n, err)
+		}
+		// the position is at the end after WriteTo.
+		if n, err := f.WriteTo(&buf); n != 0 || err != nil {
+			t.Errorf("%+v: WriteTo at the end: %d, %v", opts, n, err)
+		}
+		f.Close()
+		p.Close()
+	}
+}
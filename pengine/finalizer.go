@@ -0,0 +1,74 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"io"
+	"log"
+	"runtime"
+	"sync"
+)
+
+// finalizerWarning logs the warning of the finalizers only once.
+var finalizerWarning sync.Once
+
+// guardedFile is the file of a Paket with a finalizer, a safety net for pakets that are garbage collected without Close.
+//
+// The constructors that open a file (New, NewMmap, NewVolumes, NewFromZip, NewFromFS and OpenSelfDescribing) wrap it with guardFile.
+// If the Paket is collected before Close, the finalizer closes the file and logs a warning once.
+//
+// *os.File already has a finalizer in the runtime that closes the file descriptor, so plain files are not leaked without it.
+// This finalizer adds the warning, and releases what the runtime doesn't: the memory mapping of NewMmap and the other volumes of NewVolumes.
+// It is only a safety net, finalizers run at an unknown time or never. Always call Close.
+// Pakets that don't want its cost call DisableFinalizer (NoFinalizer of OpenOptions).
+type guardedFile struct {
+	io.Closer
+	name string
+}
+
+// guardFile returns f with a finalizer. name is for the warning, it can be empty.
+func guardFile(f io.Closer, name string) io.Closer {
+	g := &guardedFile{Closer: f, name: name}
+	runtime.SetFinalizer(g, (*guardedFile).finalize)
+	return g
+}
+
+// Close removes the finalizer and closes the file.
+func (g *guardedFile) Close() error {
+	runtime.SetFinalizer(g, nil)
+	return g.Closer.Close()
+}
+
+func (g *guardedFile) finalize() {
+	finalizerWarning.Do(func() {
+		name := g.name
+		if name == "" {
+			name = "a paket"
+		}
+		log.Printf("pengine: warning: the Paket of %s is garbage collected without Close, its file is closed by the finalizer. Call Close when you are done", name)
+	})
+	g.Closer.Close()
+}
+
+// DisableFinalizer removes the finalizer that closes the file of the Paket if it is garbage collected without Close.
+// The constructors opening a file set it, see NoFinalizer of OpenOptions. The file is not closed then if Close is forgotten
+// (except the file descriptor of plain files, which the runtime closes). Files opened by Reopen and Swap don't get a finalizer either.
+//
+// It is for programs that create many short-lived pakets and always close them, finalizers make garbage collection slower.
+func (p *Paket) DisableFinalizer() {
+	p.globMut.Lock()
+	defer p.globMut.Unlock()
+	p.noFinalizer = true
+	p.unguard()
+}
+
+// unguard removes the finalizer of the file if DisableFinalizer was called. globMut must be held.
+func (p *Paket) unguard() {
+	if g, ok := p.file.(*guardedFile); ok && p.noFinalizer {
+		runtime.SetFinalizer(g, nil)
+		p.file = g.Closer
+	}
+}
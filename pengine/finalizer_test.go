@@ -0,0 +1,91 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+type closeRecorder struct {
+	closed chan struct{}
+}
+
+func (c *closeRecorder) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestGuardFileFinalizer(t *testing.T) {
+	c := &closeRecorder{closed: make(chan struct{})}
+	guardFile(c, "")
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("the finalizer didn't close the file")
+}
+
+// DisableFinalizer must only change its own Paket, and the files of Swap must stay without a finalizer.
+func TestDisableFinalizer(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	newPath, newTable := packTest(t, map[string][]byte{"d.txt": []byte("new")}, PackOptions{})
+
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	other, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	p.DisableFinalizer()
+	if _, ok := p.file.(*guardedFile); ok {
+		t.Error("file has a finalizer after DisableFinalizer")
+	}
+	if _, ok := other.file.(*guardedFile); !ok {
+		t.Error("DisableFinalizer changed another Paket")
+	}
+
+	if err := p.Swap(newPath, newTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.file.(*guardedFile); ok {
+		t.Error("file has a finalizer after Swap")
+	}
+	if _, _, err := p.GetFile("d.txt", true, true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenNoFinalizer(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	p, err := Open(testKey, path, table, OpenOptions{NoFinalizer: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.file.(*guardedFile); ok {
+		t.Error("file has a finalizer with NoFinalizer")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, ok := p.file.(*guardedFile); ok {
+		t.Error("file has a finalizer after Reopen")
+	}
+}
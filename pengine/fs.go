@@ -0,0 +1,317 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS returns a read-only file system of the files in the Paket.
+// It can be used with http.FS, template.ParseFS and other functions working with fs.FS.
+//
+// Names with slashes are in sub folders, for example "images/ui/button.png". Unsafe names (see SanitizeName) are left out. The folders are created from the names,
+// so fs.WalkDir works. Backslashes of pakets created on Windows are used as slashes.
+// Files are decrypted when they are opened.
+// The returned value also implements fs.ReadFileFS.
+func (p *Paket) FS() fs.FS {
+	return paketFS{p: p}
+}
+
+// NewFromFS creates a new Paket from the paket file name in fsys, e.g. an embed.FS or os.DirFS:
+//
+//	//go:embed data.pack
+//	var assets embed.FS
+//
+//	p, err := pengine.NewFromFS(key, assets, "data.pack", PaketData)
+//
+// If the opened file implements io.ReaderAt (files of embed.FS and os.DirFS do), it is read directly.
+// Otherwise it is read to memory completely.
+//
+// key and table parameters are the same as New. Returns an error wrapping ErrPaketNotFound if the file doesn't exist.
+// After getting all the data you need, should be terminated with Close. It closes the file.
+func NewFromFS(key []byte, fsys fs.FS, name string, table Datas) (*Paket, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, name)
+		}
+		return nil, err
+	}
+	fInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fInfo.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%s is a folder, not a paket file", name)
+	}
+	if fInfo.Size() == 0 {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, name)
+	}
+
+	if r, ok := f.(io.ReaderAt); ok {
+		p, err := NewFromReaderAt(key, io.NewSectionReader(r, 0, fInfo.Size()), table)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// Close releases the file.
+		p.file = guardFile(f, name)
+		return p, nil
+	}
+
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromBytes(key, data, table)
+}
+
+type paketFS struct {
+	p *Paket
+}
+
+// Open implements fs.FS.
+func (pfs paketFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	key, found := pfs.p.lookup(name)
+	if !found {
+		if _, isDir := pfs.p.children(name); isDir {
+			return &paketDir{pfs: pfs, name: name}, nil
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := pfs.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &paketFile{info: fileInfo{name: path.Base(name), size: int64(len(data)), modTime: pfs.p.table()[key].ModTime}, r: bytes.NewReader(data)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (pfs paketFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	key, found := pfs.p.lookup(name)
+	if !found {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	data, _, err := pfs.p.GetFile(key, true, false)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// ReadDir returns the names of the files and folders directly in the folder dir, sorted.
+// Names of folders end with a slash. Use "" or "." for the root folder.
+//
+// Folders are created from the names of the files, like FS. Returns an error wrapping fs.ErrNotExist if there is no such folder.
+func (p *Paket) ReadDir(dir string) ([]string, error) {
+	dir = strings.Trim(slashName(dir), "/")
+	if dir == "" {
+		dir = "."
+	}
+	children, found := p.children(dir)
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: fs.ErrNotExist}
+	}
+	names := make([]string, 0, len(children))
+	for name, key := range children {
+		if key == "" {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// slashName returns the name of a file in the FS. Backslashes are replaced with slashes.
+func slashName(name string) string {
+	return strings.ReplaceAll(name, "\\", "/")
+}
+
+// ErrUnsafeName is returned by SanitizeName (wrapped with the name) for names pointing outside of a folder.
+var ErrUnsafeName = errors.New("unsafe file name")
+
+// SanitizeName checks a name of the table before using it as a path, e.g. while extracting.
+// Names are not checked when the paket is created, so a modified paket can have names like "../../etc/passwd".
+//
+// Returns the cleaned name with slashes. Empty, absolute names (also with a Windows drive) and names going out
+// of the folder with ".." return an error wrapping ErrUnsafeName.
+func SanitizeName(name string) (string, error) {
+	n := slashName(name)
+	if n == "" || path.IsAbs(n) || (len(n) >= 2 && n[1] == ':') {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeName, name)
+	}
+	clean := path.Clean(n)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeName, name)
+	}
+	return clean, nil
+}
+
+// lookup returns the name in the table of the file with the FS name.
+// FS names never have backslashes.
+func (p *Paket) lookup(name string) (string, bool) {
+	if strings.Contains(name, "\\") {
+		return "", false
+	}
+	if _, found := p.table()[name]; found {
+		return name, true
+	}
+	for key := range p.table() {
+		if n, err := SanitizeName(key); err == nil && n == name {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// children returns the files and folders directly in the folder dir ("." is the root).
+// Keys of the map are the names in the folder, values are the names in the table for files and "" for folders.
+// Returns false if there is no such folder.
+func (p *Paket) children(dir string) (map[string]string, bool) {
+	children := map[string]string{}
+	found := dir == "."
+	for key := range p.table() {
+		// unsafe names are not in the FS.
+		name, err := SanitizeName(key)
+		if err != nil {
+			continue
+		}
+		if dir != "." {
+			if !strings.HasPrefix(name, dir+"/") {
+				continue
+			}
+			name = name[len(dir)+1:]
+		}
+		found = true
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			children[name[:i]] = ""
+		} else if name != "" {
+			children[name] = key
+		}
+	}
+	return children, found
+}
+
+// paketFile is an opened file of the FS. Its content is already decrypted.
+type paketFile struct {
+	info fileInfo
+	r    *bytes.Reader
+}
+
+func (f *paketFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *paketFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+
+func (f *paketFile) Seek(offset int64, whence int) (int64, error) { return f.r.Seek(offset, whence) }
+
+func (f *paketFile) Close() error { return nil }
+
+// paketDir is a folder of the FS.
+type paketDir struct {
+	pfs  paketFS
+	name string
+	// entries not returned by ReadDir yet. Created by the first ReadDir call.
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *paketDir) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: path.Base(d.name), mode: fs.ModeDir | 0555}, nil
+}
+
+func (d *paketDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *paketDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile. Entries are sorted by name.
+func (d *paketDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		children, _ := d.pfs.p.children(d.name)
+		names := make([]string, 0, len(children))
+		for name := range children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key := children[name]
+			if key == "" {
+				d.entries = append(d.entries, fs.FileInfoToDirEntry(fileInfo{name: name, mode: fs.ModeDir | 0555}))
+				continue
+			}
+			value := d.pfs.p.table()[key]
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(fileInfo{name: name, size: value.OriginalLenght, modTime: value.ModTime}))
+		}
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo for the files and folders of the FS.
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+	// unix nanoseconds, see Values.ModTime.
+	modTime int64
+}
+
+func (fi fileInfo) Name() string { return fi.name }
+
+func (fi fileInfo) Size() int64 { return fi.size }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.mode == 0 {
+		return 0444
+	}
+	return fi.mode
+}
+
+func (fi fileInfo) ModTime() time.Time {
+	if fi.modTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, fi.modTime)
+}
+
+func (fi fileInfo) IsDir() bool { return fi.Mode().IsDir() }
+
+func (fi fileInfo) Sys() interface{} { return nil }
@@ -0,0 +1,70 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	p, files := extractTest(t)
+	defer p.Close()
+	fsys := p.FS()
+	if err := fstest.TestFS(fsys, "b.bin", "c.txt", "empty", "license", "deep/sub/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range files {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("ReadFile %s: %v", name, err)
+		}
+	}
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open of a missing file: %v, want fs.ErrNotExist", err)
+	}
+	if _, err := fsys.Open("../b.bin"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Open of an invalid path: %v, want fs.ErrInvalid", err)
+	}
+}
+
+// Backslashes of Windows names are folders, unsafe names are not in the FS.
+func TestFSNames(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	table["img\\ui\\b.bin"] = table["b.bin"]
+	table["../c.txt"] = table["c.txt"]
+	delete(table, "b.bin")
+	delete(table, "c.txt")
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	got, err := fs.ReadFile(p.FS(), "img/ui/b.bin")
+	if err != nil || !bytes.Equal(got, files["b.bin"]) {
+		t.Errorf("ReadFile of a backslash name: %v", err)
+	}
+	names, err := p.ReadDir("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.txt", "empty", "img/", "license"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir: %v, want %v", names, want)
+	}
+	if names, err := p.ReadDir("img\\ui"); err != nil || !reflect.DeepEqual(names, []string{"b.bin"}) {
+		t.Errorf("ReadDir of a sub folder: %v, %v", names, err)
+	}
+	if _, err := p.ReadDir("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadDir of a missing folder: %v, want fs.ErrNotExist", err)
+	}
+}
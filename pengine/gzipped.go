@@ -0,0 +1,53 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// GzipBufferLimit is the maximum decompressed size of a paket that NewGzipped loads to memory.
+var GzipBufferLimit int64 = 256 << 20
+
+// NewGzipped creates a new Paket from a paket file compressed with gzip as a whole (e.g. "gzip data.pack" gives data.pack.gz).
+// It makes the file to distribute smaller, if the files were not compressed before encryption (see the -compress parameter of the cmd tool).
+//
+// gzip can't be read from the middle, so the whole paket is decompressed to memory here. Opening is slow for large pakets and
+// the memory is used until the Paket is garbage collected. Pakets bigger than GzipBufferLimit return an error.
+// It is for small pakets, decompress large ones to disk once and use New.
+//
+// key and table parameters are the same as New. The file is closed before NewGzipped returns, but Close must still be called.
+func NewGzipped(key []byte, paketFileName string, table Datas) (*Paket, error) {
+	f, err := os.Open(paketFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, paketFileName)
+		}
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", paketFileName, err)
+	}
+	defer zr.Close()
+	// one byte more than the limit, to know that it is exceeded.
+	data, err := ioutil.ReadAll(io.LimitReader(zr, GzipBufferLimit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", paketFileName, err)
+	}
+	if int64(len(data)) > GzipBufferLimit {
+		return nil, fmt.Errorf("%s is too large to decompress to memory (limit %d bytes). Decompress it to disk and use New", paketFileName, GzipBufferLimit)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, paketFileName)
+	}
+	return NewFromBytes(key, data, table)
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashFunc returns the hash of the data as a hex string, like the hashes in the table.
+type HashFunc func(data []byte) string
+
+// Names of the hash algorithms for Values.HashAlgorithm and the -hash parameter of the cmd tool.
+const (
+	HashSHA256  = "sha256"
+	HashSHA512  = "sha512"
+	HashBLAKE2b = "blake2b"
+)
+
+// SHA256Hex is the default hash function of the table.
+func SHA256Hex(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// HashByName returns the hash function of the algorithm. Empty name means sha256, like old tables.
+func HashByName(name string) (HashFunc, error) {
+	switch name {
+	case "", HashSHA256:
+		return SHA256Hex, nil
+	case HashSHA512:
+		return func(data []byte) string { return fmt.Sprintf("%x", sha512.Sum512(data)) }, nil
+	case HashBLAKE2b:
+		return func(data []byte) string { return fmt.Sprintf("%x", blake2b.Sum256(data)) }, nil
+	}
+	return nil, fmt.Errorf("unknown hash algorithm: %s", name)
+}
+
+// newHash returns the hash.Hash of the algorithm, for data that is hashed while it is copied. Empty name means sha256.
+func newHash(name string) (hash.Hash, error) {
+	switch name {
+	case "", HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashBLAKE2b:
+		return blake2b.New256(nil)
+	}
+	return nil, fmt.Errorf("unknown hash algorithm: %s", name)
+}
+
+// hash calculates the hash of the data of a file for comparing with its hashes in the table.
+//
+// The algorithm recorded in the table is used. For files without a recorded algorithm,
+// HashFunc of the Paket is used if it is set, otherwise sha256.
+func (p *Paket) hash(file Values, data []byte) string {
+	if file.HashAlgorithm == "" {
+		if p.HashFunc != nil {
+			return p.HashFunc(data)
+		}
+		return SHA256Hex(data)
+	}
+	hf, err := HashByName(file.HashAlgorithm)
+	if err != nil {
+		// unknown algorithm. An empty hash doesn't match anything, so the check fails.
+		return ""
+	}
+	return hf(data)
+}
@@ -0,0 +1,236 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// HeaderMagic is written at the beginning of self-describing paket files.
+const HeaderMagic = "PAKETSD1"
+
+// HeaderVersion is the version of the header format written by WriteHeader.
+const HeaderVersion = 1
+
+// ReaderVersion is the version of the features this package can read. It is not the version of the header format,
+// it changes when pakets get features older readers would read wrong (see MinReaderVersion of Header).
+//
+//	1: the first version with MinReaderVersion.
+//	2: per entry keys, encrypted names, zstd compression and unencrypted files.
+const ReaderVersion = 2
+
+// maxHeaderSize protects ReadHeader against allocating huge buffers for corrupt files.
+const maxHeaderSize = 1 << 30
+
+var (
+	// ReadHeader and OpenSelfDescribing return this error for files that don't start with HeaderMagic.
+	// They are normal paket files, open them with New and the generated table.
+	ErrNoHeader = errors.New("paket file has no header")
+
+	// OpenSelfDescribing and Open return this error (with both versions) if the paket uses features of a newer reader.
+	// See MinReaderVersion of Header and OpenOptions.
+	ErrReaderTooOld = errors.New("paket needs a newer reader")
+)
+
+// Header is the beginning of a self-describing paket file (created with the -embed parameter of the cmd tool).
+// It contains the table, so the paket can be opened without the generated go file.
+//
+// The layout of the file is:
+//
+//	HeaderMagic (8 bytes)
+//	length of the encoded header (8 bytes, big endian)
+//	encoded header (gob)
+//	data of the files
+//
+// StartPos and EndPos in the table are relative to the beginning of the data, not the file.
+type Header struct {
+	// Version of the header format. See HeaderVersion.
+	Version int
+
+	// Encryption mode of the files.
+	Mode Mode
+
+	// If true, every file is encrypted with its own key (see EntryKey).
+	PerEntryKeys bool
+
+	// Salt of the key if it is derived from a password (see DeriveKey). Empty otherwise.
+	Salt []byte
+
+	// Information of the files.
+	Table Datas
+
+	// If true, the names in Table are encrypted (see EncryptTableNames). OpenSelfDescribing decrypts them.
+	EncryptedNames bool
+
+	// MAC of the table (see TableMAC). If it is set, OpenSelfDescribing verifies it.
+	TableMAC []byte
+
+	// Key check value (see KeyCheckValue). If it is set, OpenSelfDescribing checks the key with it.
+	KeyCheck []byte
+
+	// The oldest ReaderVersion that can read the paket. 0 means any reader.
+	// WriteHeader raises it to MinReaderVersion of the table and the settings of the header.
+	MinReaderVersion int
+
+	// Encrypted metadata blobs by name. They are not files of the paket. Set them with SetMetadataBlob.
+	Metadata map[string][]byte
+}
+
+// WriteHeader writes the header to w. The data of the files must be written after it.
+//
+// Returns the number of bytes written, which is the offset of the data.
+func WriteHeader(w io.Writer, h Header) (int64, error) {
+	h.Version = HeaderVersion
+	if v := MinReaderVersion(h.Table, h.PerEntryKeys, h.EncryptedNames); v > h.MinReaderVersion {
+		h.MinReaderVersion = v
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(h); err != nil {
+		return 0, err
+	}
+	prefix := make([]byte, len(HeaderMagic)+8)
+	copy(prefix, HeaderMagic)
+	binary.BigEndian.PutUint64(prefix[len(HeaderMagic):], uint64(buf.Len()))
+
+	if _, err := w.Write(prefix); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return int64(len(prefix) + buf.Len()), nil
+}
+
+// MinReaderVersion returns the ReaderVersion needed to read a paket with this table.
+// perEntryKeys and encryptedNames are the settings of the paket that are not in the table
+// (PackOptions.PerEntryKeys and PackOptions.EncryptNames).
+//
+// It is the only place of the rule: WriteHeader uses it for self-describing pakets and the cmd tool for the table file.
+func MinReaderVersion(table Datas, perEntryKeys, encryptedNames bool) int {
+	if perEntryKeys || encryptedNames {
+		return 2
+	}
+	for _, v := range table {
+		if v.Compression == CompressionZstd || v.Unencrypted || v.EncName != "" {
+			return 2
+		}
+	}
+	return 1
+}
+
+// CheckReaderVersion returns an error wrapping ErrReaderTooOld (with both versions) if a paket needing the reader version min
+// can't be read by this package. OpenSelfDescribing and Open call it.
+func CheckReaderVersion(min int) error {
+	if min > ReaderVersion {
+		return fmt.Errorf("%w: it needs reader version %d, this is version %d. Update paket", ErrReaderTooOld, min, ReaderVersion)
+	}
+	return nil
+}
+
+// ReadHeader reads the header from the beginning of a self-describing paket.
+//
+// Returns the header and the offset of the data. Returns ErrNoHeader if r doesn't start with HeaderMagic.
+//
+// It can be used to get the Salt before deriving the key and calling OpenSelfDescribing.
+func ReadHeader(r io.Reader) (*Header, int64, error) {
+	prefix := make([]byte, len(HeaderMagic)+8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, 0, ErrNoHeader
+		}
+		return nil, 0, err
+	}
+	if string(prefix[:len(HeaderMagic)]) != HeaderMagic {
+		return nil, 0, ErrNoHeader
+	}
+	size := binary.BigEndian.Uint64(prefix[len(HeaderMagic):])
+	if size > maxHeaderSize {
+		return nil, 0, fmt.Errorf("header is too large (%d bytes), the file is corrupt", size)
+	}
+
+	var h Header
+	if err := gob.NewDecoder(io.LimitReader(r, int64(size))).Decode(&h); err != nil {
+		return nil, 0, fmt.Errorf("header is corrupt: %w", err)
+	}
+	if h.Version > HeaderVersion {
+		return nil, 0, fmt.Errorf("header version %d is not supported, update paket", h.Version)
+	}
+	return &h, int64(len(prefix)) + int64(size), nil
+}
+
+// OpenSelfDescribing creates a new Paket from a self-describing paket file.
+// The table and the mode are read from the header of the file, no generated go file is needed.
+//
+// key is the same as New. For pakets created with a password, get the Salt with ReadHeader and use DeriveKey.
+//
+// If the paket needs a newer reader than this package (see MinReaderVersion of Header), an error wrapping ErrReaderTooOld is returned.
+// If the header has a KeyCheck, a wrong key returns ErrWrongKey.
+// If the header has a TableMAC, it is verified. A modified table or a wrong key returns an error wrapping ErrIntegrity.
+// Encrypted names (see EncryptedNames of Header) are decrypted after it.
+// The metadata blobs of the header are read with GetMetadataBlob.
+//
+// After getting all the data you need, should be terminated with  Close.
+func OpenSelfDescribing(key []byte, paketFileName string) (*Paket, error) {
+	f, err := os.Open(paketFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, paketFileName)
+		}
+		return nil, err
+	}
+	fInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	h, offset, err := ReadHeader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := CheckReaderVersion(h.MinReaderVersion); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	p, err := NewFromReaderAt(key, io.NewSectionReader(f, offset, fInfo.Size()-offset), h.Table)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	p.Mode = h.Mode
+	p.PerEntryKeys = h.PerEntryKeys
+	p.metadata = h.Metadata
+	p.KeyCheck = h.KeyCheck
+	if len(h.KeyCheck) > 0 {
+		if err := p.CheckKey(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if len(h.TableMAC) > 0 {
+		if err := p.VerifyTableMAC(h.TableMAC); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	// after the MAC, it is of the encrypted names.
+	if h.EncryptedNames {
+		if err := p.DecryptNames(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	// Close releases the file.
+	p.file = guardFile(f, paketFileName)
+	return p, nil
+}
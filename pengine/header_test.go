@@ -0,0 +1,191 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeSelfDescribing writes the header and the data of the paket at dataPath to a new self-describing paket, like the -embed parameter.
+func writeSelfDescribing(t *testing.T, dataPath string, h Header) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(dataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := WriteHeader(&buf, h); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(data)
+	out := filepath.Join(t.TempDir(), "embedded.pack")
+	if err := ioutil.WriteFile(out, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestMetadataBlob(t *testing.T) {
+	files := testFiles()
+	dataPath, table := packTest(t, files, PackOptions{})
+	h := Header{Table: table, TableMAC: TableMAC(testKey, table), KeyCheck: KeyCheckValue(testKey)}
+	license := []byte("MIT license text")
+	if err := h.SetMetadataBlob(testKey, "license", license); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetMetadataBlob(testKey, "buildlog", []byte("old log")); err != nil {
+		t.Fatal(err)
+	}
+	// setting it again replaces it.
+	if err := h.SetMetadataBlob(testKey, "buildlog", []byte("build ok")); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetMetadataBlob(testKey, "", nil); !errors.Is(err, ErrEmptyName) {
+		t.Errorf("SetMetadataBlob with empty name: %v, want ErrEmptyName", err)
+	}
+	if bytes.Contains(h.Metadata["license"], license) {
+		t.Error("metadata blob is not encrypted")
+	}
+
+	p, err := OpenSelfDescribing(testKey, writeSelfDescribing(t, dataPath, h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+
+	got, err := p.GetMetadataBlob("license")
+	if err != nil || !bytes.Equal(got, license) {
+		t.Errorf("GetMetadataBlob license: %q, %v", got, err)
+	}
+	if got, err := p.GetMetadataBlob("buildlog"); err != nil || string(got) != "build ok" {
+		t.Errorf("GetMetadataBlob buildlog: %q, %v", got, err)
+	}
+	if _, err := p.GetMetadataBlob("icon"); !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("GetMetadataBlob of a missing blob: %v, want ErrNoMetadata", err)
+	}
+	if names := p.MetadataNames(); len(names) != 2 || names[0] != "buildlog" || names[1] != "license" {
+		t.Errorf("MetadataNames: %v", names)
+	}
+	// blobs are not files, a file with the same name is not changed.
+	for _, name := range p.Keys() {
+		if name == "buildlog" {
+			t.Error("metadata blob is in the table")
+		}
+	}
+}
+
+// Header has the same getters as the Paket, for tools reading a header without opening its data.
+func TestHeaderMetadataBlob(t *testing.T) {
+	var h Header
+	if err := h.SetMetadataBlob(testKey, "license", []byte("MIT")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := h.GetMetadataBlob(testKey, "license"); err != nil || string(got) != "MIT" {
+		t.Errorf("GetMetadataBlob: %q, %v", got, err)
+	}
+	otherKey := append([]byte(nil), testKey...)
+	otherKey[0] ^= 1
+	if _, err := h.GetMetadataBlob(otherKey, "license"); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("GetMetadataBlob with a wrong key: %v, want ErrIntegrity", err)
+	}
+	if names := h.MetadataNames(); len(names) != 1 || names[0] != "license" {
+		t.Errorf("MetadataNames: %v", names)
+	}
+
+	// pakets without a header have no metadata.
+	path, table := packTest(t, testFiles(), PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.GetMetadataBlob("license"); !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("GetMetadataBlob without a header: %v, want ErrNoMetadata", err)
+	}
+}
+
+func TestMetadataBlobModified(t *testing.T) {
+	dataPath, table := packTest(t, testFiles(), PackOptions{})
+	h := Header{Table: table}
+	if err := h.SetMetadataBlob(testKey, "license", []byte("MIT")); err != nil {
+		t.Fatal(err)
+	}
+	// a blob moved under another name doesn't decrypt.
+	h.Metadata["icon"] = h.Metadata["license"]
+	p, err := OpenSelfDescribing(testKey, writeSelfDescribing(t, dataPath, h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if _, err := p.GetMetadataBlob("icon"); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("renamed blob: %v, want ErrIntegrity", err)
+	}
+}
+
+func TestMinReaderVersion(t *testing.T) {
+	dataPath, table := packTest(t, testFiles(), PackOptions{})
+
+	p, err := OpenSelfDescribing(testKey, writeSelfDescribing(t, dataPath, Header{Table: table}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	_, err = OpenSelfDescribing(testKey, writeSelfDescribing(t, dataPath, Header{Table: table, MinReaderVersion: ReaderVersion + 1}))
+	if !errors.Is(err, ErrReaderTooOld) {
+		t.Fatalf("OpenSelfDescribing of a newer paket: %v, want ErrReaderTooOld", err)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(ReaderVersion+1)) || !strings.Contains(err.Error(), strconv.Itoa(ReaderVersion)) {
+		t.Errorf("error doesn't contain both versions: %v", err)
+	}
+}
+
+func TestMinReaderVersionRaised(t *testing.T) {
+	for _, opts := range []PackOptions{{PerEntryKeys: true}, {EncryptNames: true}, {Compression: CompressionZstd}, {Plain: []string{"*.txt"}}} {
+		_, table := packTest(t, testFiles(), opts)
+		if v := MinReaderVersion(table, opts.PerEntryKeys, opts.EncryptNames); v != 2 {
+			t.Errorf("MinReaderVersion of %+v: %d, want 2", opts, v)
+		}
+	}
+	_, table := packTest(t, testFiles(), PackOptions{Compression: CompressionGzip})
+	if v := MinReaderVersion(table, false, false); v != 1 {
+		t.Errorf("MinReaderVersion without gated features: %d, want 1", v)
+	}
+	// WriteHeader raises it for the features in the table.
+	_, table = packTest(t, testFiles(), PackOptions{Compression: CompressionZstd})
+	var buf bytes.Buffer
+	if _, err := WriteHeader(&buf, Header{Table: table}); err != nil {
+		t.Fatal(err)
+	}
+	h, _, err := ReadHeader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.MinReaderVersion != 2 {
+		t.Errorf("MinReaderVersion of a zstd paket: %d, want 2", h.MinReaderVersion)
+	}
+}
+
+// pakets with a generated table are checked by Open.
+func TestOpenMinReaderVersion(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	_, err := Open(testKey, path, table, OpenOptions{MinReaderVersion: ReaderVersion + 1})
+	if !errors.Is(err, ErrReaderTooOld) {
+		t.Fatalf("Open of a newer paket: %v, want ErrReaderTooOld", err)
+	}
+	p, err := Open(testKey, path, table, OpenOptions{MinReaderVersion: ReaderVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// NewHTTP creates a new Paket that reads the paket from a web server (a CDN, object storage...) with HTTP range requests.
+// Only the encrypted data of the requested files is downloaded, nothing is stored on the disk.
+//
+// Every read is a request: "Range: bytes=start-end" for the region of the file. Use GetFile or GetFiles, they read a file at once.
+// Streaming functions (OpenReader, File) read in chunks of BufferSize, so they make more requests.
+//
+// If the server doesn't support range requests (it answers with the whole file), a warning is logged and the whole paket is
+// downloaded to memory once. The next reads are served from memory. The paket can't be larger than the largest EndPos of the table then,
+// a larger answer returns an error instead of filling the memory.
+//
+// client is the HTTP client of the requests, nil means http.DefaultClient. Set its Timeout for slow networks.
+// key and table parameters are the same as New.
+func NewHTTP(key []byte, paketURL string, table Datas, client *http.Client) (*Paket, error) {
+	u, err := url.Parse(paketURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%s is not an http or https URL", paketURL)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var size int64
+	for _, v := range table {
+		if v.EndPos > size {
+			size = v.EndPos
+		}
+	}
+	return NewFromReaderAt(key, &httpReaderAt{client: client, url: paketURL, size: size}, table)
+}
+
+// httpReaderAt reads from a paket on a web server with range requests. It is safe for concurrent use.
+type httpReaderAt struct {
+	client *http.Client
+	url    string
+	// size of the paket, the largest EndPos of the table. Limit of the download without ranges.
+	size int64
+
+	// the whole paket, if the server doesn't support ranges. Protected by mut.
+	// mut is held during the download, so other reads wait for it instead of downloading the paket again.
+	mut  sync.Mutex
+	full *bytes.Reader
+	// set if the whole paket can't be downloaded, it is returned by all reads then.
+	fullErr error
+}
+
+// ReadAt requests the range of b from the server.
+func (h *httpReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	h.mut.Lock()
+	full, fullErr := h.full, h.fullErr
+	h.mut.Unlock()
+	if fullErr != nil {
+		return 0, fullErr
+	}
+	if full != nil {
+		return full.ReadAt(b, off)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(b))-1))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		n, err := io.ReadFull(resp.Body, b)
+		if err == io.ErrUnexpectedEOF {
+			// the range was shortened by the server, it is after the end of the paket.
+			err = io.EOF
+		}
+		return n, err
+	case http.StatusRequestedRangeNotSatisfiable:
+		return 0, io.EOF
+	case http.StatusOK:
+		// the server ignored the range, the body is the whole paket.
+		full, err := h.download(resp)
+		if err != nil {
+			return 0, err
+		}
+		return full.ReadAt(b, off)
+	}
+	return 0, fmt.Errorf("%s: %s", h.url, resp.Status)
+}
+
+// download reads the whole paket from the answer of a server without range support. See ReadAt.
+// If another read has already downloaded it, that one is returned and resp is not read.
+func (h *httpReaderAt) download(resp *http.Response) (*bytes.Reader, error) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if h.fullErr != nil {
+		return nil, h.fullErr
+	}
+	if h.full != nil {
+		return h.full, nil
+	}
+
+	log.Printf("pengine: warning: %s doesn't support range requests, the whole paket is downloaded to memory", h.url)
+	tooLarge := fmt.Errorf("%s doesn't support range requests and it is larger than the paket (%d bytes), it is not downloaded", h.url, h.size)
+	if resp.ContentLength > h.size {
+		h.fullErr = tooLarge
+		return nil, tooLarge
+	}
+	// one byte more than the paket, to find out if the answer is larger.
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, h.size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > h.size {
+		h.fullErr = tooLarge
+		return nil, tooLarge
+	}
+	h.full = bytes.NewReader(data)
+	return h.full, nil
+}
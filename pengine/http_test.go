@@ -0,0 +1,121 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewHTTP(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	var requests int32
+	fileServer := http.FileServer(http.Dir(filepath.Dir(path)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "" {
+			t.Errorf("request without Range")
+		}
+		atomic.AddInt32(&requests, 1)
+		fileServer.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	p, err := NewHTTP(testKey, server.URL+"/"+filepath.Base(path), table, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+	if atomic.LoadInt32(&requests) == 0 {
+		t.Error("no requests are made")
+	}
+
+	if _, err := NewHTTP(testKey, "ftp://example.com/data.pack", table, nil); err == nil {
+		t.Error("NewHTTP accepts an ftp URL")
+	}
+}
+
+// noRangeServer serves data without range support, every answer is the whole data. It counts the answers.
+func noRangeServer(data []byte, answers *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(answers, 1)
+		w.Write(data)
+	}))
+}
+
+func TestNewHTTPWithoutRanges(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answers int32
+	server := noRangeServer(data, &answers)
+	defer server.Close()
+
+	p, err := NewHTTP(testKey, server.URL, table, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	got, err := p.GetFiles(p.Keys(), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range files {
+		if !bytes.Equal(got[name], want) {
+			t.Errorf("%s: content is not the original file", name)
+		}
+	}
+	// after the download, reads are served from memory.
+	before := atomic.LoadInt32(&answers)
+	checkFiles(t, p, files)
+	if after := atomic.LoadInt32(&answers); after != before {
+		t.Errorf("%d requests after the paket is downloaded", after-before)
+	}
+}
+
+func TestNewHTTPWithoutRangesTooLarge(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answers int32
+	server := noRangeServer(append(data, make([]byte, 1<<20)...), &answers)
+	defer server.Close()
+
+	p, err := NewHTTP(testKey, server.URL, table, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	if _, _, err := p.GetFile("a.txt", true, false); err == nil {
+		t.Fatal("GetFile from a larger answer succeeded")
+	}
+	// the error is kept, the paket is not downloaded again.
+	if _, _, err := p.GetFile("b.bin", true, false); err == nil {
+		t.Fatal("second GetFile succeeded")
+	}
+	if n := atomic.LoadInt32(&answers); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import "sort"
+
+// PaketInfo is the summary of a table, returned by Info.
+type PaketInfo struct {
+	// Number of files.
+	Count int
+
+	// Total length of the original files.
+	OriginalSize int64
+
+	// Total length of the encrypted data, the size of the paket (without the header of self-describing pakets).
+	EncryptedSize int64
+
+	// Number of compressed files.
+	Compressed int
+
+	// Encrypted size of the compressed files divided by their original size, e.g. 0.4 means they are 60% smaller.
+	// 0 if no file is compressed.
+	CompressionRatio float64
+
+	// Names of the largest and the smallest file by original length. Empty for an empty table.
+	// If several files have the same length, the first name in sorted order is used.
+	Largest, Smallest string
+}
+
+// Info returns the summary of the table: number of files, sizes, compression ratio and the largest and smallest files.
+// Only the table is used, the paket is not read.
+func (d Datas) Info() PaketInfo {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	info := PaketInfo{Count: len(d)}
+	var compOriginal, compEncrypted int64
+	for _, name := range names {
+		v := d[name]
+		info.OriginalSize += v.OriginalLenght
+		info.EncryptedSize += v.EncryptLenght
+		if v.compression() != CompressionNone {
+			info.Compressed++
+			compOriginal += v.OriginalLenght
+			compEncrypted += v.EncryptLenght
+		}
+		if info.Largest == "" || v.OriginalLenght > d[info.Largest].OriginalLenght {
+			info.Largest = name
+		}
+		if info.Smallest == "" || v.OriginalLenght < d[info.Smallest].OriginalLenght {
+			info.Smallest = name
+		}
+	}
+	if compOriginal > 0 {
+		info.CompressionRatio = float64(compEncrypted) / float64(compOriginal)
+	}
+	return info
+}
+
+// Info returns the summary of the table of the Paket. See Datas.Info.
+func (p *Paket) Info() PaketInfo {
+	return p.table().Info()
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+// BufferSizeError is returned by GetInto if the buffer is too small. Need is the size required for the file.
+// It matches io.ErrShortBuffer with errors.Is.
+type BufferSizeError struct {
+	Name string
+	Need int64
+}
+
+func (e *BufferSizeError) Error() string {
+	return fmt.Sprintf("buffer is too small for %s, %d bytes are needed", e.Name, e.Need)
+}
+
+func (e *BufferSizeError) Unwrap() error { return io.ErrShortBuffer }
+
+// GetInto reads the file into dst and returns the number of bytes written. If decrypt is false, the encrypted data is read.
+// It is for loops reading many files with the same buffer, without allocating a new slice for every file.
+//
+// If dst is too small, a *BufferSizeError with the needed size is returned. Resize dst and call again.
+//
+// CFB files without compression are decrypted in dst, unencrypted ones are read in dst as they are. Compressed and GCM files,
+// and the files of pakets with a cache (NewRemoteCached) are read like GetFile and copied.
+//
+// The checks are the same for all files: the IV is checked like GetFile does (see StrictIV), and files with a MAC in the table
+// are checked with it, an error wrapping ErrIntegrity is returned if it doesn't match. The hashes are not compared,
+// so files without a MAC are not authenticated, like GetFile without shaControl. Use GetFile for them if it matters.
+func (p *Paket) GetInto(filename string, dst []byte, decrypt bool) (int, error) {
+	file, section, err := p.section(filename)
+	if err != nil {
+		return 0, err
+	}
+	need := file.EncryptLenght
+	if decrypt {
+		need = file.OriginalLenght
+	}
+	if int64(len(dst)) < need {
+		return 0, &BufferSizeError{Name: filename, Need: need}
+	}
+
+	// unencrypted files without compression are read like the encrypted data, there is nothing to decrypt.
+	raw := !decrypt || (file.Unencrypted && file.compression() == CompressionNone)
+	if raw {
+		data := dst[:need]
+		if _, err := io.ReadFull(section, data); err != nil {
+			return 0, regionError(filename, file.StartPos, file.EncryptLenght, err)
+		}
+		if err := p.checkInto(filename, file, data); err != nil {
+			return 0, err
+		}
+		return int(need), nil
+	}
+
+	if !p.isCFB() || file.compression() != CompressionNone || p.cacheDir != "" {
+		// with shaControl, GetFile checks the MAC. Without a MAC, the hash is not compared.
+		content, ok, err := p.GetFile(filename, true, file.MAC != "")
+		if err != nil {
+			return 0, err
+		}
+		if file.MAC != "" && !ok {
+			WipeKey(content)
+			return 0, fmt.Errorf("%w: MAC of %s doesn't match", ErrIntegrity, filename)
+		}
+		n := copy(dst, content)
+		WipeKey(content)
+		return n, nil
+	}
+
+	if file.EncryptLenght < IVSize {
+		return 0, fmt.Errorf("%w: %s", ErrShortCiphertext, filename)
+	}
+	if file.EncryptLenght-IVSize != need {
+		return 0, regionError(filename, file.StartPos, file.EncryptLenght, io.ErrUnexpectedEOF)
+	}
+	var iv [IVSize]byte
+	if _, err := io.ReadFull(section, iv[:]); err != nil {
+		return 0, regionError(filename, file.StartPos, file.EncryptLenght, err)
+	}
+	data := dst[:need]
+	if _, err := io.ReadFull(section, data); err != nil {
+		return 0, regionError(filename, file.StartPos, file.EncryptLenght, err)
+	}
+	if err := p.checkInto(filename, file, iv[:], data); err != nil {
+		return 0, err
+	}
+	block, err := p.fileBlock(filename)
+	if err != nil {
+		return 0, err
+	}
+	cipher.NewCFBDecrypter(block, iv[:]).XORKeyStream(data, data)
+	return int(need), nil
+}
+
+// checkInto does the checks of GetFile on the encrypted data read by GetInto: the IV and the MAC, if the file has one.
+func (p *Paket) checkInto(filename string, file Values, encData ...[]byte) error {
+	if !file.Unencrypted {
+		if err := p.checkIV(filename, encData...); err != nil {
+			return err
+		}
+	}
+	if file.MAC != "" {
+		return p.checkMAC(filename, file, encData...)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestGetInto(t *testing.T) {
+	for _, opts := range []PackOptions{
+		{},
+		{Mode: ModeGCM},
+		{Compression: CompressionGzip, MAC: true},
+		{Plain: []string{"*.txt"}, MAC: true},
+	} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		buf := make([]byte, 1<<16)
+		for name, want := range files {
+			n, err := p.GetInto(name, buf, true)
+			if err != nil || !bytes.Equal(buf[:n], want) {
+				t.Errorf("%+v: GetInto %s: %v", opts, name, err)
+			}
+			enc, _, err := p.GetFile(name, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, err := p.GetInto(name, buf, false); err != nil || !bytes.Equal(buf[:n], enc) {
+				t.Errorf("%+v: GetInto %s without decrypt: %v", opts, name, err)
+			}
+		}
+		p.Close()
+	}
+}
+
+func TestGetIntoShortBuffer(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	_, err = p.GetInto("a.txt", make([]byte, 10), true)
+	var sizeErr *BufferSizeError
+	if !errors.As(err, &sizeErr) || !errors.Is(err, io.ErrShortBuffer) {
+		t.Fatalf("GetInto with a small buffer: %v, want *BufferSizeError", err)
+	}
+	if sizeErr.Need != int64(len(files["a.txt"])) {
+		t.Errorf("Need: %d, want %d", sizeErr.Need, len(files["a.txt"]))
+	}
+}
+
+// The fast path of GetInto does the checks of GetFile: the MAC and the IV.
+func TestGetIntoChecks(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{MAC: true})
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a flipped byte of the encrypted data of a.txt.
+	b := make([]byte, 1)
+	pos := table["a.txt"].StartPos + IVSize + 5
+	if _, err := f.ReadAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 1
+	if _, err := f.WriteAt(b, pos); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	copyIV(t, path, table, "c.txt", "license")
+
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	buf := make([]byte, 1<<16)
+	if _, err := p.GetInto("a.txt", buf, true); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("GetInto of a modified file: %v, want ErrIntegrity", err)
+	}
+	if _, err := p.GetInto("a.txt", buf, false); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("GetInto without decrypt of a modified file: %v, want ErrIntegrity", err)
+	}
+
+	p.StrictIV = true
+	if _, err := p.GetInto("c.txt", buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetInto("license", buf, true); !errors.Is(err, ErrWeakIV) {
+		t.Errorf("GetInto of a reused IV with StrictIV: %v, want ErrWeakIV", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// copyIV writes the IV of the file from over the IV of the file to in the paket.
+func copyIV(t *testing.T, path string, table Datas, from, to string) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	iv := make([]byte, IVSize)
+	if _, err := f.ReadAt(iv, table[from].StartPos); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(iv, table[to].StartPos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckIVReused(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{Minimal: true})
+	copyIV(t, path, table, "a.txt", "b.bin")
+
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.StrictIV = true
+	if _, _, err := p.GetFile("a.txt", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.GetFile("b.bin", false, false); !errors.Is(err, ErrWeakIV) {
+		t.Errorf("reused IV with StrictIV: %v, want ErrWeakIV", err)
+	}
+
+	// without StrictIV, the warning is logged once for the file.
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	p.StrictIV = false
+	for i := 0; i < 3; i++ {
+		if _, _, err := p.GetFile("b.bin", false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(logs.String(), "is the same as the IV of a.txt"); n != 1 {
+		t.Errorf("warnings for 3 reads: %d, want 1\n%s", n, logs.String())
+	}
+}
+
+// Deterministic packing gives the same IV to files with the same content, it is not a weak IV.
+func TestCheckIVDeterministic(t *testing.T) {
+	files := map[string][]byte{"a.txt": []byte("same content"), "copy.txt": []byte("same content"), "b.txt": []byte("other")}
+	for _, mode := range []Mode{ModeCFB, ModeGCM} {
+		path, table := packTest(t, files, PackOptions{Mode: mode, Deterministic: true})
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = mode
+		p.StrictIV = true
+		checkFiles(t, p, files)
+		p.Close()
+	}
+}
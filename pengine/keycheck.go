@@ -0,0 +1,47 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// keyCheckText is the known text authenticated by KeyCheckValue.
+const keyCheckText = "paket key check"
+
+var (
+	// CheckKey returns this error if the key of the Paket doesn't match the key check value.
+	ErrWrongKey = errors.New("wrong key")
+
+	// CheckKey returns this error if the Paket has no key check value.
+	ErrNoKeyCheck = errors.New("paket has no key check value")
+)
+
+// KeyCheckValue calculates a value that shows whether a key is right, without revealing the key.
+// It is an HMAC-SHA256 of a known text, with a key derived from key (see subKey).
+//
+// CFB decryption never fails, a wrong key only gives garbage. The cmd tool writes this value as PaketKeyCheck
+// to the table file and to the header of self-describing pakets, so wrong keys can be detected when opening.
+func KeyCheckValue(key []byte) []byte {
+	m := hmac.New(sha256.New, subKey(key, "paket key check"))
+	m.Write([]byte(keyCheckText))
+	return m.Sum(nil)
+}
+
+// CheckKey compares the Key of the Paket with its KeyCheck.
+//
+// Returns ErrWrongKey if they don't match, ErrNoKeyCheck if KeyCheck is empty.
+func (p *Paket) CheckKey() error {
+	if len(p.KeyCheck) == 0 {
+		return ErrNoKeyCheck
+	}
+	if !hmac.Equal(p.KeyCheck, KeyCheckValue(p.Key)) {
+		return ErrWrongKey
+	}
+	return nil
+}
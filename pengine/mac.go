@@ -0,0 +1,142 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// TableMAC calculates an HMAC-SHA256 of the table with a key derived from key (see subKey).
+//
+// The per-file hashes protect the data, but not the table itself. Someone who can edit the table
+// could change the positions of the files. The MAC covers every field of every entry, so such changes are detected.
+//
+// The cmd tool writes it as PaketTableMAC to the table file, and to the header of self-describing pakets.
+func TableMAC(key []byte, table Datas) []byte {
+	m := hmac.New(sha256.New, subKey(key, "paket table mac"))
+	m.Write(tableBytes(table))
+	return m.Sum(nil)
+}
+
+// VerifyTableMAC compares mac with the MAC of the table of the Paket (see TableMAC).
+// The MAC is calculated with MetaKey, or with Key if MetaKey is nil.
+//
+// Returns nil if they are equal, an error wrapping ErrIntegrity otherwise.
+func (p *Paket) VerifyTableMAC(mac []byte) error {
+	if !hmac.Equal(mac, TableMAC(p.metaKey(), p.table())) {
+		return fmt.Errorf("%w: table MAC doesn't match", ErrIntegrity)
+	}
+	return nil
+}
+
+// EntryMAC calculates the HMAC-SHA256 of the encrypted data of a file with a key derived from key, as hex.
+// It is stored in the MAC field of Values.
+//
+// The hashes in the table can be recalculated by anyone who modifies a file. The MAC can't without the key.
+func EntryMAC(key, encData []byte) string {
+	m := hmac.New(sha256.New, entryMACKey(key))
+	m.Write(encData)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// entryMACKey returns the HMAC key of EntryMAC. Also used by the streaming packer.
+func entryMACKey(key []byte) []byte {
+	return subKey(key, "paket entry mac")
+}
+
+// checkMAC compares the MAC of the file in the table with the MAC of its encrypted data.
+// The encrypted data can be given in parts, they are MACed one after the other.
+// Returns an error wrapping ErrIntegrity if they are different.
+func (p *Paket) checkMAC(filename string, file Values, encData ...[]byte) error {
+	m := hmac.New(sha256.New, entryMACKey(p.metaKey()))
+	for _, part := range encData {
+		m.Write(part)
+	}
+	if !HashEqual(hex.EncodeToString(m.Sum(nil)), file.MAC) {
+		return fmt.Errorf("%w: MAC of %s doesn't match", ErrIntegrity, filename)
+	}
+	return nil
+}
+
+// metaKey returns the key of the MACs: MetaKey if it is set, Key otherwise.
+func (p *Paket) metaKey() []byte {
+	if p.MetaKey != nil {
+		return p.MetaKey
+	}
+	return p.Key
+}
+
+// tableBytes encodes the table in a stable form for TableMAC.
+// Entries are sorted by name, every value is length-prefixed so fields can't be shifted into each other.
+//
+// New fields of Values must be added here, otherwise they are not protected by the MAC.
+// Use a new tag for them, like MAC.
+func tableBytes(table Datas) []byte {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	putInt := func(v int64) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(v))
+		buf = append(buf, b[:]...)
+	}
+	putString := func(s string) {
+		putInt(int64(len(s)))
+		buf = append(buf, s...)
+	}
+
+	for _, name := range names {
+		v := table[name]
+		putString(name)
+		putInt(v.StartPos)
+		putInt(v.EndPos)
+		putInt(v.OriginalLenght)
+		putInt(v.EncryptLenght)
+		putString(v.HashOriginal)
+		putString(v.HashEncrypt)
+		putString(v.HashAlgorithm)
+		putInt(v.ModTime)
+		if v.Compressed {
+			putInt(1)
+		} else {
+			putInt(0)
+		}
+		// fields added later are written only when they are set, after a negative tag.
+		// Names are prefixed with their length, so a tag can't be read as the next name. MACs of old tables stay valid.
+		if v.MAC != "" {
+			putInt(-1)
+			putString(v.MAC)
+		}
+		if v.Compression != CompressionNone {
+			putInt(-2)
+			putInt(int64(v.Compression))
+		}
+		if v.CRC != 0 {
+			putInt(-3)
+			putInt(int64(v.CRC))
+		}
+		if v.NameAAD {
+			putInt(-4)
+		}
+		if v.EncName != "" {
+			putInt(-5)
+			putString(v.EncName)
+		}
+		if v.Unencrypted {
+			putInt(-6)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// The key of the paket must not be the HMAC key of anything, every purpose has its own derived key.
+func TestSubKeys(t *testing.T) {
+	raw := func(data []byte) []byte {
+		m := hmac.New(sha256.New, testKey)
+		m.Write(data)
+		return m.Sum(nil)
+	}
+	data := []byte("encrypted data")
+	if EntryMAC(testKey, data) == hex.EncodeToString(raw(data)) {
+		t.Error("EntryMAC uses the key directly")
+	}
+	table := Datas{"a": {StartPos: 0, EndPos: 10}}
+	if bytes.Equal(TableMAC(testKey, table), raw(tableBytes(table))) {
+		t.Error("TableMAC uses the key directly")
+	}
+	if bytes.Equal(KeyCheckValue(testKey), raw([]byte(keyCheckText))) {
+		t.Error("KeyCheckValue uses the key directly")
+	}
+
+	seen := make(map[string]string)
+	for _, purpose := range []string{"paket table mac", "paket entry mac", "paket key check", "paket synthetic iv", "paket names", "paket metadata"} {
+		k := string(subKey(testKey, purpose))
+		if other, found := seen[k]; found {
+			t.Errorf("%s and %s have the same key", purpose, other)
+		}
+		seen[k] = purpose
+		if k == string(testKey) {
+			t.Errorf("key of %s is the key of the paket", purpose)
+		}
+	}
+}
+
+// Streamed files get their MAC while they are written, it must be the same as EntryMAC.
+func TestPackMACStreamed(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{MAC: true, StreamSize: 100})
+	for name, v := range table {
+		if v.MAC == "" {
+			t.Errorf("%s has no MAC", name)
+		}
+	}
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+	if err := p.VerifyTableMAC(TableMAC(testKey, table)); err != nil {
+		t.Error(err)
+	}
+	failed, err := p.Verify()
+	if err != nil || len(failed) > 0 {
+		t.Errorf("Verify: %v, %v", failed, err)
+	}
+}
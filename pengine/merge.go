@@ -0,0 +1,88 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Merge copies the files of several pakets into a new paket dst and returns the table of dst.
+// tables[i] is the table of srcs[i]. It is for pakets built in parallel, e.g. one paket per folder.
+//
+// Like Compact, the encrypted data is copied as it is, nothing is decrypted or encrypted again.
+// So all pakets must be created with the same key and the same settings (mode, -entrykeys...), key is the key of all of them.
+// The files are written in the order of srcs, the files of a paket in sorted order. Positions are recalculated, other fields are not changed.
+// The table MAC of dst must be calculated again with TableMAC.
+//
+// The encrypted data of every file is checked with HashEncrypt before copying, a corrupt file returns an error wrapping ErrIntegrity.
+// A name that is in more than one paket returns an error, files are not renamed (renaming breaks pakets created with -entrykeys or -bindnames).
+//
+// dst must not exist. It is removed if Merge fails.
+func Merge(dst string, srcs []string, tables []Datas, key []byte) (Datas, error) {
+	if len(srcs) != len(tables) {
+		return nil, fmt.Errorf("%d pakets but %d tables are given", len(srcs), len(tables))
+	}
+	if len(srcs) == 0 {
+		return nil, errors.New("no pakets are given")
+	}
+	// names are checked before dst is created.
+	from := make(map[string]string)
+	for i, table := range tables {
+		for name := range table {
+			if other, found := from[name]; found {
+				return nil, fmt.Errorf("%s is in both %s and %s", name, other, srcs[i])
+			}
+			from[name] = srcs[i]
+		}
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergeTo(out, srcs, tables, key)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return nil, err
+	}
+	return merged, nil
+}
+
+// mergeTo writes the encrypted data of the pakets to out. See Merge.
+func mergeTo(out *os.File, srcs []string, tables []Datas, key []byte) (Datas, error) {
+	merged := make(Datas)
+	var pos int64
+	for i, src := range srcs {
+		p, err := New(key, src, tables[i])
+		if err != nil {
+			return nil, err
+		}
+		table, err := p.compactTo(out, p.Keys())
+		p.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+		// compactTo starts from 0, the files of this paket are after the previous ones.
+		var end int64
+		for name, v := range table {
+			v.StartPos += pos
+			v.EndPos += pos
+			if v.EndPos > end {
+				end = v.EndPos
+			}
+			merged[name] = v
+		}
+		if end > pos {
+			pos = end
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	files := testFiles()
+	first := map[string][]byte{"a.txt": files["a.txt"], "b.bin": files["b.bin"]}
+	second := map[string][]byte{"c.txt": files["c.txt"], "empty": files["empty"], "license": files["license"]}
+	path1, table1 := packTest(t, first, PackOptions{MAC: true})
+	path2, table2 := packTest(t, second, PackOptions{MAC: true})
+
+	dst := filepath.Join(t.TempDir(), "merged.pack")
+	merged, err := Merge(dst, []string{path1, path2}, []Datas{table1, table2}, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != len(files) {
+		t.Fatalf("%d files in the merged table, want %d", len(merged), len(files))
+	}
+	// the files of the second paket are after the first one.
+	var end1 int64
+	for name := range first {
+		if merged[name].EndPos > end1 {
+			end1 = merged[name].EndPos
+		}
+	}
+	for name := range second {
+		if merged[name].StartPos < end1 {
+			t.Errorf("%s starts at %d, in the files of the first paket", name, merged[name].StartPos)
+		}
+	}
+	p, err := New(testKey, dst, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+	if failed, err := p.Verify(); err != nil || len(failed) > 0 {
+		t.Errorf("Verify: %v, %v", failed, err)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	files := testFiles()
+	path1, table1 := packTest(t, files, PackOptions{})
+	path2, table2 := packTest(t, map[string][]byte{"a.txt": []byte("other a")}, PackOptions{})
+	dst := filepath.Join(t.TempDir(), "merged.pack")
+
+	if _, err := Merge(dst, []string{path1}, []Datas{table1, table2}, testKey); err == nil {
+		t.Error("Merge with more tables than pakets succeeded")
+	}
+	if _, err := Merge(dst, []string{path1, path2}, []Datas{table1, table2}, testKey); err == nil {
+		t.Error("Merge with a name in two pakets succeeded")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("dst is created for a name in two pakets: %v", err)
+	}
+
+	path3, table3 := packTest(t, map[string][]byte{"d.txt": []byte("content of d")}, PackOptions{})
+	flipByte(t, path3, table3["d.txt"].StartPos+IVSize)
+	if _, err := Merge(dst, []string{path1, path3}, []Datas{table1, table3}, testKey); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("Merge of a modified paket: %v, want ErrIntegrity", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("dst is not removed after a failed Merge: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// GetMetadataBlob returns this error (wrapped with the name) if the paket has no blob with that name.
+var ErrNoMetadata = errors.New("metadata blob not found")
+
+// metadataKey derives the key of the metadata blobs from the key of the paket, like nameKey.
+func metadataKey(key []byte) ([]byte, error) {
+	return subKey(key, "paket metadata"), nil
+}
+
+// SetMetadataBlob encrypts data with a key derived from key and stores it in the header under name.
+// Metadata blobs are for data that is not a normal file of the paket: a license, a build log, an icon...
+// They are not in the table, so Keys, Walk and the other functions listing files don't see them.
+//
+// The blob is encrypted with GCM and its name is authenticated with it, so blobs can't be modified or renamed.
+// Setting a name again replaces the blob. Read them with GetMetadataBlob, of the Header or of the Paket created by OpenSelfDescribing.
+func (h *Header) SetMetadataBlob(key []byte, name string, data []byte) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	mk, err := metadataKey(key)
+	if err != nil {
+		return err
+	}
+	defer WipeKey(mk)
+	enc, err := EncryptGCMAAD(mk, data, []byte(name))
+	if err != nil {
+		return err
+	}
+	if h.Metadata == nil {
+		h.Metadata = make(map[string][]byte)
+	}
+	h.Metadata[name] = enc
+	return nil
+}
+
+// GetMetadataBlob decrypts the metadata blob with the given name, set with SetMetadataBlob and the same key.
+//
+// Returns an error wrapping ErrNoMetadata if there is no blob with this name,
+// and ErrIntegrity if the blob was modified or the key is wrong.
+func (h *Header) GetMetadataBlob(key []byte, name string) ([]byte, error) {
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+	enc, found := h.Metadata[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNoMetadata, name)
+	}
+	mk, err := metadataKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer WipeKey(mk)
+	data, err := DecryptGCMAAD(mk, enc, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("metadata blob %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// MetadataNames returns the sorted names of the metadata blobs.
+func (h *Header) MetadataNames() []string {
+	names := make([]string, 0, len(h.Metadata))
+	for name := range h.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetMetadataBlob decrypts the metadata blob of the header with the key of the Paket, like GetMetadataBlob of Header.
+//
+// Only pakets created with OpenSelfDescribing have metadata, the others return an error wrapping ErrNoMetadata.
+func (p *Paket) GetMetadataBlob(name string) ([]byte, error) {
+	h := Header{Metadata: p.metadata}
+	return h.GetMetadataBlob(p.Key, name)
+}
+
+// MetadataNames returns the sorted names of the metadata blobs of the header, like MetadataNames of Header.
+func (p *Paket) MetadataNames() []string {
+	h := Header{Metadata: p.metadata}
+	return h.MetadataNames()
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapping is the paket file of NewMmap. It is a memory mapping on unix systems (see mmap_unix.go), the file on others.
+// The systems are listed in the build constraints, the "unix" constraint needs Go 1.19 and go.mod is for Go 1.16.
+type mapping interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// NewMmap creates a new Paket like New, but the paket file is mapped to memory instead of being read with system calls.
+// The data comes from the page cache of the OS, so reading many files from a large paket is faster, e.g. in a server.
+//
+// The paket file must not be changed or truncated while it is mapped, reading a truncated part can crash the program.
+// The mapping is released with Close.
+//
+// The mapping is done with the mmap system call, only on unix systems (Linux, macOS, the BSDs, Solaris and AIX).
+// On others (Windows), NewMmap opens the file like New.
+func NewMmap(key []byte, paketFileName string, table Datas) (*Paket, error) {
+	if err := ValidKeyLength(key); err != nil {
+		return nil, err
+	}
+	if len(table) == 0 {
+		return nil, ErrMinimumMapValue
+	}
+	fInfo, err := os.Stat(paketFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, paketFileName)
+		}
+		return nil, err
+	}
+	if fInfo.Size() == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, paketFileName)
+	}
+
+	m, err := openMapping(paketFileName)
+	if err != nil {
+		return nil, err
+	}
+	return &Paket{file: guardFile(m, paketFileName), reader: m, Table: table, Key: key, paketFileName: paketFileName, mmapped: true}, nil
+}
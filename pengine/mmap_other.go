@@ -0,0 +1,17 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package pengine
+
+import "os"
+
+// openMapping opens the file for NewMmap. Memory mapping is only used on unix systems,
+// others read the file with ReadAt like New.
+func openMapping(name string) (mapping, error) {
+	return os.Open(name)
+}
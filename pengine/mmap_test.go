@@ -0,0 +1,53 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewMmap(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{Compression: CompressionGzip})
+	p, err := NewMmap(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, p, files)
+
+	got, err := p.ReadRange("a.txt", 10, 20, true)
+	if err != nil || string(got) != string(files["a.txt"][10:30]) {
+		t.Errorf("ReadRange: %q, %v", got, err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.GetFile("a.txt", true, false); err != ErrClosed {
+		t.Errorf("GetFile after Close: %v, want ErrClosed", err)
+	}
+	// Reopen maps the file again.
+	if err := p.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, p, files)
+	p.Close()
+}
+
+func TestNewMmapErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewMmap(testKey, filepath.Join(dir, "missing"), Datas{"a": {}}); !errors.Is(err, ErrPaketNotFound) {
+		t.Errorf("NewMmap of a missing file: %v, want ErrPaketNotFound", err)
+	}
+	empty := filepath.Join(dir, "empty")
+	ioutil.WriteFile(empty, nil, 0600)
+	if _, err := NewMmap(testKey, empty, Datas{"a": {}}); !errors.Is(err, ErrEmptyPaket) {
+		t.Errorf("NewMmap of an empty file: %v, want ErrEmptyPaket", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pengine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapReader is a read-only memory mapping of a whole file.
+// ReadAt and Close are not synchronized, Paket protects them with globMut.
+type mmapReader struct {
+	data []byte
+}
+
+// openMapping maps the file to memory.
+func openMapping(name string) (mapping, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// the mapping stays valid after the file is closed.
+	defer f.Close()
+	fInfo, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fInfo.Size()
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, name)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("%s is too large to map on this system", name)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", name, err)
+	}
+	return &mmapReader{data: data}, nil
+}
+
+// ReadAt copies the mapped bytes from off to b.
+func (m *mmapReader) ReadAt(b []byte, off int64) (int, error) {
+	if m.data == nil {
+		return 0, ErrClosed
+	}
+	if off < 0 || off > int64(len(m.data)) {
+		return 0, errors.New("invalid offset of the mapping")
+	}
+	n := copy(b, m.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file. Calling it more than once is safe.
+func (m *mmapReader) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data := m.data
+	m.data = nil
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// nameKey derives the key of the names from the key of the paket, so it is not used for two things.
+func nameKey(key []byte) ([]byte, error) {
+	return subKey(key, "paket names"), nil
+}
+
+// NameID returns the ID of a file in a table with encrypted names (see EncryptTableNames).
+// It is the hex HMAC-SHA256 of the name, with a key derived from key. Without the key, the name can't be found from the ID.
+func NameID(key []byte, name string) (string, error) {
+	nk, err := nameKey(key)
+	if err != nil {
+		return "", err
+	}
+	defer WipeKey(nk)
+	return nameID(nk, name), nil
+}
+
+// nameID is NameID with the derived key.
+func nameID(nk []byte, name string) string {
+	m := hmac.New(sha256.New, nk)
+	m.Write([]byte(name))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// EncryptTableNames returns a copy of the table that hides the names of the files, for pakets whose list of files is secret.
+// Every name is replaced with its NameID, and the name is stored encrypted (GCM) in EncName.
+// The table is not modified. Pack does it with EncryptNames of PackOptions (-encryptnames of the cmd tool).
+//
+// Readers get the names back with DecryptTableNames, Paket.DecryptNames or EncryptedNames of OpenOptions.
+// The table MAC (see TableMAC) is calculated and checked with the encrypted names.
+func EncryptTableNames(key []byte, table Datas) (Datas, error) {
+	nk, err := nameKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer WipeKey(nk)
+	c, err := NewCrypter(nk)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Datas, len(table))
+	for name, v := range table {
+		enc, err := c.EncryptGCM([]byte(name), nil)
+		if err != nil {
+			return nil, err
+		}
+		v.EncName = hex.EncodeToString(enc)
+		out[nameID(nk, name)] = v
+	}
+	return out, nil
+}
+
+// DecryptTableNames returns a copy of the table created by EncryptTableNames, with the names of the files.
+// EncName of the returned Values is empty. The table is not modified.
+//
+// Returns an error wrapping ErrIntegrity if a name can't be decrypted (a wrong key or a modified table),
+// or if it doesn't belong to its ID (names swapped in the table).
+func DecryptTableNames(key []byte, table Datas) (Datas, error) {
+	nk, err := nameKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer WipeKey(nk)
+	c, err := NewCrypter(nk)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Datas, len(table))
+	for id, v := range table {
+		enc, err := hex.DecodeString(v.EncName)
+		if err != nil || v.EncName == "" {
+			return nil, fmt.Errorf("%w: %s has no valid encrypted name", ErrIntegrity, id)
+		}
+		name, err := c.DecryptGCM(enc, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: name of %s can't be decrypted", ErrIntegrity, id)
+		}
+		if !HashEqual(nameID(nk, string(name)), id) {
+			return nil, fmt.Errorf("%w: name of %s doesn't belong to it", ErrIntegrity, id)
+		}
+		v.EncName = ""
+		out[string(name)] = v
+	}
+	return out, nil
+}
+
+// DecryptNames replaces the table of a paket with encrypted names (see EncryptTableNames) with the decrypted one.
+// The names are only decrypted in memory. After it, files are read with their names as usual.
+//
+// Call VerifyTableMAC before it, the table MAC is of the encrypted names.
+// Open does both with TableMAC and EncryptedNames of OpenOptions, OpenSelfDescribing does them automatically.
+func (p *Paket) DecryptNames() error {
+	p.tableMut.Lock()
+	defer p.tableMut.Unlock()
+	table, err := DecryptTableNames(p.Key, p.Table)
+	if err != nil {
+		return err
+	}
+	p.Table = table
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncryptNames(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{EncryptNames: true})
+	for id, v := range table {
+		if _, found := files[id]; found || v.EncName == "" {
+			t.Fatalf("name %s is not encrypted", id)
+		}
+	}
+
+	p, err := Open(testKey, path, table, OpenOptions{TableMAC: TableMAC(testKey, table), EncryptedNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+	if len(p.Keys()) != len(files) {
+		t.Errorf("Keys: %v", p.Keys())
+	}
+}
+
+func TestDecryptNamesWrongKey(t *testing.T) {
+	_, table := packTest(t, testFiles(), PackOptions{EncryptNames: true})
+	if _, err := DecryptTableNames([]byte("fedcba9876543210fedcba9876543210"), table); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("DecryptTableNames with a wrong key: %v, want ErrIntegrity", err)
+	}
+}
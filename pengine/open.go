@@ -0,0 +1,127 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpenOptions are the options of Open. The zero value does no checks, Open is then the same as New.
+type OpenOptions struct {
+	// Encryption mode of the paket. Set to ModeGCM for pakets created with "-mode gcm".
+	Mode Mode
+
+	// Cipher of the paket, if it was created with a custom Cipher. If it is set, Mode is not used.
+	Cipher Cipher
+
+	// If true, the files are decrypted with their own keys (see Paket.PerEntryKeys).
+	PerEntryKeys bool
+
+	// Key of the table MAC and the file MACs, if it is different from the key (see Paket.MetaKey).
+	MetaKey []byte
+
+	// Key check value of the paket (PaketKeyCheck of the table file). It is set as KeyCheck of the Paket.
+	KeyCheck []byte
+
+	// If true, the key is checked with CheckKey. KeyCheck must be set.
+	CheckKey bool
+
+	// MAC of the table (PaketTableMAC of the table file). If it is set, it is checked with VerifyTableMAC.
+	TableMAC []byte
+
+	// Checksum of the paket file (PaketChecksum of the table file). If it is set, it is compared with FileChecksum first.
+	// It reads the whole paket, like VerifyOnOpen.
+	Checksum string
+
+	// If true, the positions in the table are checked with ValidateTable.
+	ValidateTable bool
+
+	// If true, the names in the table are encrypted (see EncryptTableNames). They are decrypted after the table MAC is checked.
+	EncryptedNames bool
+
+	// If true, all files are checked with Verify. It reads the whole paket, it can be slow for large pakets.
+	VerifyOnOpen bool
+
+	// ReaderVersion needed by the paket (PaketMinReaderVersion of the table file). If it is newer than ReaderVersion,
+	// Open returns an error wrapping ErrReaderTooOld before opening the file.
+	MinReaderVersion int
+
+	// If true, the Paket has no finalizer closing its file if it is garbage collected without Close (see DisableFinalizer).
+	NoFinalizer bool
+}
+
+// Open creates a new Paket like New and checks it with the options.
+// Checks are done in the order: reader version, checksum, table structure, key, table MAC, files.
+// With EncryptedNames, the names are decrypted before the files are checked.
+//
+// Returns the error of the first failed check. The Paket is closed then.
+// Verify failures return an error wrapping ErrIntegrity with the names of the files.
+func Open(key []byte, paketFileName string, table Datas, opts OpenOptions) (*Paket, error) {
+	if err := CheckReaderVersion(opts.MinReaderVersion); err != nil {
+		return nil, err
+	}
+	p, err := New(key, paketFileName, table)
+	if err != nil {
+		return nil, err
+	}
+	p.Mode = opts.Mode
+	p.Cipher = opts.Cipher
+	p.PerEntryKeys = opts.PerEntryKeys
+	p.MetaKey = opts.MetaKey
+	p.KeyCheck = opts.KeyCheck
+	if opts.NoFinalizer {
+		p.DisableFinalizer()
+	}
+	if err := p.openChecks(opts); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// openChecks does the checks of Open.
+func (p *Paket) openChecks(opts OpenOptions) error {
+	if opts.Checksum != "" {
+		sum, err := FileChecksum(p.paketFileName)
+		if err != nil {
+			return err
+		}
+		if !HashEqual(sum, strings.ToLower(opts.Checksum)) {
+			return fmt.Errorf("%w: checksum of %s is %s, expected %s", ErrIntegrity, p.paketFileName, sum, opts.Checksum)
+		}
+	}
+	if opts.ValidateTable {
+		if err := p.ValidateTable(); err != nil {
+			return err
+		}
+	}
+	if opts.CheckKey {
+		if err := p.CheckKey(); err != nil {
+			return err
+		}
+	}
+	if len(opts.TableMAC) > 0 {
+		if err := p.VerifyTableMAC(opts.TableMAC); err != nil {
+			return err
+		}
+	}
+	if opts.EncryptedNames {
+		if err := p.DecryptNames(); err != nil {
+			return err
+		}
+	}
+	if opts.VerifyOnOpen {
+		failed, err := p.Verify()
+		if err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%w: %s", ErrIntegrity, strings.Join(failed, ", "))
+		}
+	}
+	return nil
+}
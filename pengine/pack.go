@@ -0,0 +1,774 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// PackOptions are the options of Pack. The zero value packs with ModeCFB, sha256 hashes and without compression.
+type PackOptions struct {
+	// Encryption mode of the data.
+	Mode Mode
+
+	// Cipher of the data. If it is set, Mode is not used. Readers must set the same Cipher to Paket.
+	Cipher Cipher
+
+	// Name of the hash algorithm (HashSHA256, HashSHA512 or HashBLAKE2b). Empty means sha256.
+	Hash string
+
+	// Compresses the files with gzip before encryption. Files that don't get smaller are stored without compression.
+	// Same as Compression: CompressionGzip. Compression is used if it is set.
+	Compress bool
+
+	// Compression algorithm of the files. Files that don't get smaller are stored without compression.
+	Compression Compression
+
+	// Minimal writes only the positions and lengths to the table, without hashes, CRCs and modification times.
+	Minimal bool
+
+	// MAC stores the MAC of each file in the table (see EntryMAC). GetFile checks it with the key.
+	MAC bool
+
+	// Key of the file MACs. If it is nil, the key of the files is used. See Paket.MetaKey.
+	MetaKey []byte
+
+	// PerEntryKeys encrypts every file with its own key, derived from the key and the name of the file (see EntryKey).
+	// Readers must set PerEntryKeys of Paket. The MACs are still calculated with the key (or MetaKey).
+	PerEntryKeys bool
+
+	// BindNames authenticates the name of every file with its data (see EncryptGCMAAD), so the data of two files
+	// can't be swapped in the table. Works only with ModeGCM. Values.NameAAD is set, readers need nothing else.
+	BindNames bool
+
+	// Deterministic derives the IV of every file from the key and the content (see EncryptDeterministic) instead of a random one.
+	// The same files and key give a byte-identical paket, so it can be verified by its hash or cached by its content.
+	//
+	// Warning: files with the same content have the same encrypted data, someone without the key can see this.
+	// Their IVs are the same too, StrictIV of Paket accepts this (the encrypted data is the same). Works only with ModeCFB and ModeGCM.
+	Deterministic bool
+
+	// SelfTest reads every file back from w after writing it, decrypts it and compares it with the original file and its hash.
+	// A mismatch stops Pack with an error wrapping ErrIntegrity, so bugs and write errors are found while packing.
+	// w must implement io.ReaderAt (like *os.File). Packing is slower, use it for release builds.
+	SelfTest bool
+
+	// Files larger than StreamSize (in bytes) are encrypted while they are copied to w, like PackStream, instead of being loaded to memory.
+	// So files larger than the memory can be packed. 0 means all files are loaded to memory.
+	// Streamed files are not compressed and are encrypted one by one, when the files before them are written.
+	// Works only with ModeCFB, without Deterministic.
+	StreamSize int64
+
+	// EncryptNames hides the names of the files in the returned table (see EncryptTableNames).
+	// Readers set EncryptedNames of OpenOptions, or call DecryptNames of Paket.
+	EncryptNames bool
+
+	// Plain files are stored without encryption, for files that are public anyway (licenses, readmes) and must be read fast.
+	// The patterns are matched against the base names of the files with filepath.Match, like "*.txt" or "LICENSE".
+	// Values.Unencrypted is set for these files. It is covered by the table MAC, so an encrypted file can't be marked as plain.
+	// Plain files may still be compressed, they are never streamed and BindNames doesn't cover them.
+	Plain []string
+
+	// Sync is for PackFile. If it is true, the paket file is flushed to the disk (fsync) before the rename
+	// and its folder after the rename, so neither the data nor the new name is lost after a power loss.
+	// Syncing waits for the disk, it can take long on slow storage (SD cards, network disks). Without it, packing is
+	// still atomic, but a power loss right after packing can leave an empty or partly written paket.
+	Sync bool
+
+	// Number of files encrypted at the same time. Less than 1 means runtime.NumCPU().
+	// The output is the same for every value.
+	Workers int
+
+	// Progress is called after each file is written, if it is not nil.
+	// done is the number of written files, total is len(files). It is called from the goroutine of Pack.
+	Progress func(name string, done, total int)
+}
+
+// PackFile packs the files like Pack to the file paketFileName, atomically.
+//
+// The paket is written to a temporary file in the same folder, closed and renamed to paketFileName.
+// If packing fails, the temporary file is removed and paketFileName is not touched, so a failed or interrupted packing
+// never leaves a truncated paket. An existing paketFileName is replaced. The file is created with mode 0644.
+//
+// Set Sync of opts to flush the file and its folder to the disk, see PackOptions.Sync.
+func PackFile(paketFileName string, key []byte, files []string, opts PackOptions) (Datas, error) {
+	dir := filepath.Dir(paketFileName)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(paketFileName)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	table, err := Pack(tmp, key, files, opts)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err == nil && opts.Sync {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), paketFileName)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if opts.Sync {
+		if err := SyncDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// SyncFile flushes a closed file to the disk.
+func SyncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// SyncDir flushes the directory entries of the folder, so new and renamed files are not lost after a power loss.
+// Windows doesn't support syncing folders, it is skipped there.
+func SyncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// packResult is the result of encrypting a file. Created by the workers of Pack, written in the order of the files.
+type packResult struct {
+	value   Values
+	encData []byte
+	// the original file, only for SelfTest.
+	content []byte
+	// true if the file is larger than StreamSize. It is not read by the worker, Pack streams it to w.
+	stream bool
+	err    error
+}
+
+// Pack encrypts the files and writes them to w. It is what the cmd tool uses.
+//
+// The names in the returned table are the base names of the files (filepath.Base), so they must be unique.
+// Positions are relative to the first byte written to w.
+//
+// Files are encrypted in parallel but written in the order of files, so the table doesn't depend on which file finishes first.
+// On error, the data written to w so far is not usable.
+// PackFile writes to a file atomically, so a failed packing leaves nothing behind.
+func Pack(w io.Writer, key []byte, files []string, opts PackOptions) (Datas, error) {
+	encrypt, err := opts.encrypter(key)
+	if err != nil {
+		return nil, err
+	}
+	hashFunc, err := HashByName(opts.Hash)
+	if err != nil {
+		return nil, err
+	}
+	// check reads the files back for SelfTest. base is the position of w in the file, positions are relative to it.
+	var check *Paket
+	var base int64
+	if opts.SelfTest {
+		r, ok := w.(io.ReaderAt)
+		if !ok {
+			return nil, errors.New("SelfTest needs a writer with ReadAt, like *os.File")
+		}
+		if s, ok := w.(io.Seeker); ok {
+			if base, err = s.Seek(0, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		check = &Paket{reader: r, Key: key, Mode: opts.Mode, Cipher: opts.Cipher, PerEntryKeys: opts.PerEntryKeys}
+	}
+	table := make(Datas, len(files))
+	for _, f := range files {
+		name := filepath.Base(f)
+		if _, found := table[name]; found {
+			return nil, errors.New(name + " is given more than once")
+		}
+		table[name] = Values{}
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	// sem bounds both the running workers and the results waiting to be written.
+	// It is acquired in file order, so the next file to write always has a worker.
+	sem := make(chan struct{}, workers)
+	done := make(chan struct{})
+	defer close(done)
+	results := make([]chan packResult, len(files))
+	for i := range results {
+		results[i] = make(chan packResult, 1)
+	}
+	go func() {
+		for i, f := range files {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func(f string, res chan<- packResult) {
+				res <- packFile(encrypt, hashFunc, key, f, opts)
+			}(f, results[i])
+		}
+	}()
+
+	var pos int64
+	for i, res := range results {
+		r := <-res
+		if r.err != nil {
+			return nil, r.err
+		}
+		v := r.value
+		// hash of the streamed file for SelfTest, also for minimal tables.
+		var streamHash string
+		if r.stream {
+			if v, streamHash, err = streamFile(w, key, files[i], opts); err != nil {
+				return nil, err
+			}
+		} else if _, err := w.Write(r.encData); err != nil {
+			return nil, err
+		}
+		<-sem
+		name := filepath.Base(files[i])
+		v.StartPos = pos
+		pos += v.EncryptLenght
+		v.EndPos = pos
+		if !opts.Minimal && opts.Hash != "" && opts.Hash != HashSHA256 {
+			v.HashAlgorithm = opts.Hash
+		}
+		if check != nil {
+			if r.stream {
+				err = check.selfTestStream(base, name, v, opts.Hash, streamHash)
+			} else {
+				err = check.selfTest(base, name, v, r, hashFunc)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		table[name] = v
+		if opts.Progress != nil {
+			opts.Progress(name, i+1, len(files))
+		}
+	}
+	if opts.EncryptNames {
+		return EncryptTableNames(key, table)
+	}
+	return table, nil
+}
+
+// encrypter checks the options and returns the function encrypting the files with them, for Pack and Append.
+func (opts PackOptions) encrypter(key []byte) (func(key, data []byte) ([]byte, error), error) {
+	c := opts.Cipher
+	if c == nil {
+		c = opts.Mode.Cipher()
+	}
+	if c == nil {
+		return nil, fmt.Errorf("unknown mode %d", opts.Mode)
+	}
+	encrypt := c.Encrypt
+	if _, gcm := c.(AESGCM); opts.BindNames && !gcm {
+		return nil, errors.New("BindNames works only with ModeGCM")
+	}
+	if opts.Deterministic {
+		switch c.(type) {
+		case AESCFB:
+			encrypt = EncryptDeterministic
+		case AESGCM:
+			encrypt = EncryptGCMDeterministic
+		default:
+			return nil, errors.New("Deterministic works only with ModeCFB and ModeGCM")
+		}
+	} else if !opts.PerEntryKeys {
+		// every file is encrypted with the same key, so the key schedule is calculated once.
+		switch c.(type) {
+		case AESCFB:
+			cr, err := NewCrypter(key)
+			if err != nil {
+				return nil, err
+			}
+			encrypt = func(_, data []byte) ([]byte, error) { return cr.Encrypt(data) }
+		case AESGCM:
+			cr, err := NewCrypter(key)
+			if err != nil {
+				return nil, err
+			}
+			encrypt = func(_, data []byte) ([]byte, error) { return cr.EncryptGCM(data, nil) }
+		}
+	}
+	if _, cfb := c.(AESCFB); opts.StreamSize > 0 && (!cfb || opts.Deterministic) {
+		return nil, errors.New("StreamSize works only with ModeCFB, without Deterministic")
+	}
+	for _, pattern := range opts.Plain {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("plain pattern %q: %w", pattern, err)
+		}
+	}
+	return encrypt, nil
+}
+
+// packFile reads and encrypts a file for Pack. Positions of the returned Values are not set.
+func packFile(encrypt func(key, data []byte) ([]byte, error), hashFunc HashFunc, key []byte, path string, opts PackOptions) packResult {
+	fInfo, err := os.Stat(path)
+	if err != nil {
+		return packResult{err: err}
+	}
+	plain := opts.plain(path)
+	if !plain && opts.StreamSize > 0 && fInfo.Size() > opts.StreamSize {
+		return packResult{stream: true}
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return packResult{err: err}
+	}
+	r := packData(encrypt, hashFunc, key, filepath.Base(path), content, plain, opts)
+	if r.err == nil && !opts.Minimal {
+		r.value.ModTime = fInfo.ModTime().UnixNano()
+	}
+	return r
+}
+
+// packData compresses and encrypts the content of a file for Pack and Append. Positions and ModTime of the returned Values are not set.
+func packData(encrypt func(key, data []byte) ([]byte, error), hashFunc HashFunc, key []byte, fileName string, content []byte, plain bool, opts PackOptions) packResult {
+	data, compression, err := opts.compress(content)
+	if err != nil {
+		return packResult{err: err}
+	}
+	encKey := key
+	if opts.PerEntryKeys {
+		if encKey, err = EntryKey(key, fileName); err != nil {
+			return packResult{err: err}
+		}
+	}
+	var encData []byte
+	name := []byte(fileName)
+	switch {
+	case plain:
+		encData = data
+	case opts.BindNames && opts.Deterministic:
+		encData, err = encryptGCMDeterministicAAD(encKey, data, name)
+	case opts.BindNames:
+		encData, err = EncryptGCMAAD(encKey, data, name)
+	default:
+		encData, err = encrypt(encKey, data)
+	}
+	if err != nil {
+		return packResult{err: err}
+	}
+	v := Values{OriginalLenght: int64(len(content)), EncryptLenght: int64(len(encData)), Compression: compression, NameAAD: opts.BindNames && !plain, Unencrypted: plain}
+	if !opts.Minimal {
+		// hashes are not written to minimal tables, so we don't calculate them.
+		v.HashOriginal = hashFunc(content)
+		v.HashEncrypt = hashFunc(encData)
+		v.CRC = CRC(encData)
+		if opts.Hash != "" && opts.Hash != HashSHA256 {
+			v.HashAlgorithm = opts.Hash
+		}
+	}
+	if opts.MAC {
+		macKey := key
+		if opts.MetaKey != nil {
+			macKey = opts.MetaKey
+		}
+		v.MAC = EntryMAC(macKey, encData)
+	}
+	r := packResult{value: v, encData: encData}
+	if opts.SelfTest {
+		r.content = content
+	}
+	return r
+}
+
+// streamFile encrypts a file larger than StreamSize while it is written to w. See PackOptions.StreamSize.
+// Positions of the returned Values are not set. The second value is the hash of the file, also for minimal tables.
+func streamFile(w io.Writer, key []byte, path string, opts PackOptions) (Values, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Values{}, "", err
+	}
+	defer f.Close()
+	fInfo, err := f.Stat()
+	if err != nil {
+		return Values{}, "", err
+	}
+	name := filepath.Base(path)
+	encKey := key
+	if opts.PerEntryKeys {
+		if encKey, err = EntryKey(key, name); err != nil {
+			return Values{}, "", err
+		}
+	}
+	var macKey []byte
+	if opts.MAC {
+		macKey = key
+		if opts.MetaKey != nil {
+			macKey = opts.MetaKey
+		}
+	}
+	v, err := streamEncrypt(w, encKey, f, opts.Hash, macKey)
+	if err != nil {
+		return Values{}, "", fmt.Errorf("%s: %w", name, err)
+	}
+	sum := v.HashOriginal
+	if opts.Minimal {
+		v = Values{OriginalLenght: v.OriginalLenght, EncryptLenght: v.EncryptLenght, MAC: v.MAC}
+	} else {
+		v.ModTime = fInfo.ModTime().UnixNano()
+	}
+	return v, sum, nil
+}
+
+// streamEncrypt copies r to w encrypted with Encrypt (CFB mode). The hashes (of the algorithm hashName), the CRC
+// and the MAC (if macKey is not nil) are calculated while the data is copied. Positions of the returned Values are not set.
+func streamEncrypt(w io.Writer, key []byte, r io.Reader, hashName string, macKey []byte) (Values, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return Values{}, err
+	}
+	iv := make([]byte, IVSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return Values{}, err
+	}
+	plainHash, err := newHash(hashName)
+	if err != nil {
+		return Values{}, err
+	}
+	encHash, _ := newHash(hashName)
+
+	// the encrypted data goes to w and to its hash, CRC and MAC. The plain data goes to its hash.
+	crc := crc32.New(crcTable)
+	encWriters := []io.Writer{w, encHash, crc}
+	var mac hash.Hash
+	if macKey != nil {
+		mac = hmac.New(sha256.New, entryMACKey(macKey))
+		encWriters = append(encWriters, mac)
+	}
+	encOut := io.MultiWriter(encWriters...)
+	if _, err := encOut.Write(iv); err != nil {
+		return Values{}, err
+	}
+	sw := &cipher.StreamWriter{S: cipher.NewCFBEncrypter(block, iv), W: encOut}
+	n, err := io.Copy(io.MultiWriter(sw, plainHash), r)
+	if err != nil {
+		return Values{}, err
+	}
+
+	v := Values{
+		OriginalLenght: n,
+		EncryptLenght:  IVSize + n,
+		HashOriginal:   hex.EncodeToString(plainHash.Sum(nil)),
+		HashEncrypt:    hex.EncodeToString(encHash.Sum(nil)),
+		CRC:            crc.Sum32(),
+	}
+	if mac != nil {
+		v.MAC = hex.EncodeToString(mac.Sum(nil))
+	}
+	return v, nil
+}
+
+// selfTestStream is selfTest for the files streamed by Pack. The file is decrypted while it is read back,
+// and the hash of the decrypted data (of the algorithm hashName) is compared with sum.
+func (p *Paket) selfTestStream(base int64, name string, v Values, hashName, sum string) error {
+	section := io.NewSectionReader(p.reader, base+v.StartPos, v.EncryptLenght)
+	iv := make([]byte, IVSize)
+	if _, err := io.ReadFull(section, iv); err != nil {
+		return fmt.Errorf("self test of %s: reading back: %w", name, err)
+	}
+	block, err := p.fileBlock(name)
+	if err != nil {
+		return fmt.Errorf("self test of %s: %w", name, err)
+	}
+	h, err := newHash(hashName)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(h, &cipher.StreamReader{S: cipher.NewCFBDecrypter(block, iv), R: section})
+	if err != nil {
+		return fmt.Errorf("self test of %s: reading back: %w", name, err)
+	}
+	if n != v.OriginalLenght || !HashEqual(hex.EncodeToString(h.Sum(nil)), sum) {
+		return fmt.Errorf("%w: self test of %s: decrypted data is not the original file", ErrIntegrity, name)
+	}
+	return nil
+}
+
+// selfTest reads the file written by Pack back, decrypts it and compares it with the original. See PackOptions.SelfTest.
+func (p *Paket) selfTest(base int64, name string, v Values, r packResult, hashFunc HashFunc) error {
+	written := make([]byte, len(r.encData))
+	if _, err := p.reader.ReadAt(written, base+v.StartPos); err != nil {
+		return fmt.Errorf("self test of %s: reading back: %w", name, err)
+	}
+	if !bytes.Equal(written, r.encData) {
+		return fmt.Errorf("%w: self test of %s: the data at %d-%d is not the data written", ErrIntegrity, name, v.StartPos, v.EndPos)
+	}
+	data, err := p.decrypt(name, v, written)
+	if err != nil {
+		return fmt.Errorf("self test of %s: decrypting: %w", name, err)
+	}
+	if !bytes.Equal(data, r.content) {
+		return fmt.Errorf("%w: self test of %s: decrypted data is not the original file", ErrIntegrity, name)
+	}
+	if v.HashOriginal != "" && !HashEqual(hashFunc(data), v.HashOriginal) {
+		return fmt.Errorf("%w: self test of %s: hash of the decrypted data is not HashOriginal", ErrIntegrity, name)
+	}
+	return nil
+}
+
+// Append encrypts data and adds it to the end of an existing paket file, without rebuilding the paket.
+//
+// table is the current table of the paket. It is not modified, it is used to refuse names that already exist.
+// Add the returned Values to the table with name as key.
+//
+// opts must be the options the paket was packed with. The data is compressed and encrypted like Pack does
+// (Mode, Cipher, PerEntryKeys, BindNames, MAC, Hash, Minimal, Plain, Deterministic...), so the new entry is read like the others.
+// An error is returned if the options don't match the entries of the table (MAC, hash algorithm, minimal table, BindNames).
+// Mode and PerEntryKeys are not visible in the table, they can't be checked.
+//
+// Pakets with encrypted names (EncryptNames) and self-describing pakets (their table is in the header) are refused, repack them.
+func Append(paketFileName string, key []byte, table Datas, name string, data []byte, opts PackOptions) (Values, error) {
+	if name == "" {
+		return Values{}, ErrEmptyName
+	}
+	if _, found := table[name]; found {
+		return Values{}, errors.New(name + " is already in the table")
+	}
+	if opts.EncryptNames {
+		return Values{}, errors.New("pakets with encrypted names can't be appended")
+	}
+	if err := checkAppendOptions(table, opts); err != nil {
+		return Values{}, err
+	}
+	encrypt, err := opts.encrypter(key)
+	if err != nil {
+		return Values{}, err
+	}
+	hashFunc, err := HashByName(opts.Hash)
+	if err != nil {
+		return Values{}, err
+	}
+
+	f, err := os.OpenFile(paketFileName, os.O_RDWR, 0)
+	if err != nil {
+		return Values{}, err
+	}
+	defer f.Close()
+	if _, _, err := ReadHeader(f); err != ErrNoHeader {
+		if err == nil {
+			err = errors.New("self-describing pakets can't be appended, their table is in the header")
+		}
+		return Values{}, err
+	}
+	fInfo, err := f.Stat()
+	if err != nil {
+		return Values{}, err
+	}
+
+	r := packData(encrypt, hashFunc, key, name, data, opts.plain(name), opts)
+	if r.err != nil {
+		return Values{}, r.err
+	}
+	v := r.value
+	v.StartPos = fInfo.Size()
+	v.EndPos = v.StartPos + v.EncryptLenght
+	if _, err := f.WriteAt(r.encData, v.StartPos); err != nil {
+		return Values{}, err
+	}
+	if err := f.Close(); err != nil {
+		return Values{}, err
+	}
+	return v, nil
+}
+
+// checkAppendOptions returns an error if the entries of the table were not packed with opts, see Append.
+func checkAppendOptions(table Datas, opts PackOptions) error {
+	hashAlgorithm := ""
+	if !opts.Minimal && opts.Hash != "" && opts.Hash != HashSHA256 {
+		hashAlgorithm = opts.Hash
+	}
+	for name, v := range table {
+		switch {
+		case v.EncName != "":
+			return errors.New("pakets with encrypted names can't be appended")
+		case (v.MAC != "") != opts.MAC:
+			return fmt.Errorf("MAC of the options doesn't match the entry %s", name)
+		case (v.HashOriginal == "") != opts.Minimal:
+			return fmt.Errorf("Minimal of the options doesn't match the entry %s", name)
+		case !opts.Minimal && v.HashAlgorithm != hashAlgorithm:
+			return fmt.Errorf("hash algorithm of the options doesn't match the entry %s (%q)", name, v.HashAlgorithm)
+		case !v.Unencrypted && v.NameAAD != opts.BindNames:
+			return fmt.Errorf("BindNames of the options doesn't match the entry %s", name)
+		}
+	}
+	return nil
+}
+
+// compress compresses the content with the compression of the options.
+// If the compressed data is not smaller, content is returned with CompressionNone.
+func (opts PackOptions) compress(content []byte) ([]byte, Compression, error) {
+	compression := opts.Compression
+	if compression == CompressionNone && opts.Compress {
+		compression = CompressionGzip
+	}
+	if compression == CompressionNone {
+		return content, CompressionNone, nil
+	}
+	cdata, err := CompressWith(compression, content)
+	if err != nil {
+		return nil, CompressionNone, err
+	}
+	if len(cdata) >= len(content) {
+		return content, CompressionNone, nil
+	}
+	return cdata, compression, nil
+}
+
+// plain reports whether the file must be stored without encryption. See PackOptions.Plain.
+func (opts PackOptions) plain(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range opts.Plain {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PackEstimate is the result of EstimatePack.
+type PackEstimate struct {
+	// Total length of the files.
+	Original int64
+
+	// Length of the paket that Pack would create.
+	Packed int64
+
+	// Estimates of the files, in the order of the files given to EstimatePack.
+	Files []FileEstimate
+}
+
+// FileEstimate is the estimate of a file in PackEstimate.
+type FileEstimate struct {
+	// Name in the table (base name of the file).
+	Name string
+
+	// Length of the file.
+	Original int64
+
+	// Length of the encrypted data in the paket.
+	Packed int64
+
+	// Compression that would be used for the file. CompressionNone if it doesn't get smaller.
+	Compression Compression
+}
+
+// EstimatePack calculates the size of the paket that Pack would create with the same files and options, without writing anything.
+//
+// Custom ciphers (Cipher of PackOptions) are not supported, their overhead is not known.
+//
+// Without compression, only the sizes of the files are read. With compression, every file is read and compressed,
+// so the result is exact but it takes nearly as long as packing.
+func EstimatePack(files []string, opts PackOptions) (PackEstimate, error) {
+	if opts.Cipher != nil {
+		return PackEstimate{}, errors.New("the size of a custom cipher can't be estimated")
+	}
+	overhead := int64(IVSize)
+	switch opts.Mode {
+	case ModeCFB:
+	case ModeGCM:
+		// nonce and the authentication tag.
+		overhead = GCMNonceSize + 16
+	default:
+		return PackEstimate{}, fmt.Errorf("unknown mode %d", opts.Mode)
+	}
+	compressing := opts.Compression != CompressionNone || opts.Compress
+
+	est := PackEstimate{Files: make([]FileEstimate, 0, len(files))}
+	for _, path := range files {
+		fInfo, err := os.Stat(path)
+		if err != nil {
+			return PackEstimate{}, err
+		}
+		plain := opts.plain(path)
+		fileOverhead := overhead
+		if plain {
+			fileOverhead = 0
+		}
+		fe := FileEstimate{Name: filepath.Base(path), Original: fInfo.Size(), Packed: fInfo.Size() + fileOverhead}
+		// streamed files are not compressed (see StreamSize).
+		if compressing && (plain || opts.StreamSize <= 0 || fInfo.Size() <= opts.StreamSize) {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return PackEstimate{}, err
+			}
+			data, compression, err := opts.compress(content)
+			if err != nil {
+				return PackEstimate{}, err
+			}
+			fe.Original = int64(len(content))
+			fe.Packed = int64(len(data)) + fileOverhead
+			fe.Compression = compression
+		}
+		est.Original += fe.Original
+		est.Packed += fe.Packed
+		est.Files = append(est.Files, fe)
+	}
+	return est, nil
+}
+
+// PackStream encrypts the data of r while it is copied to w, so the file is never loaded to memory completely.
+// It is for files whose size is not known before, e.g. generated outputs. The data is encrypted with Encrypt (CFB mode)
+// and is not compressed.
+//
+// The returned Values has the lengths, the sha256 hashes and the CRC. Add it to the table with name as key.
+// name is only used in the errors.
+//
+// StartPos is the current position of w if it is an io.Seeker (like *os.File), otherwise 0.
+// For other writers, add the number of bytes written before to StartPos and EndPos.
+//
+// Pack encrypts the files larger than StreamSize of PackOptions the same way.
+func PackStream(w io.Writer, key []byte, name string, r io.Reader) (Values, error) {
+	var start int64
+	if s, ok := w.(io.Seeker); ok {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return Values{}, fmt.Errorf("%s: %w", name, err)
+		}
+		start = pos
+	}
+	v, err := streamEncrypt(w, key, r, HashSHA256, nil)
+	if err != nil {
+		return Values{}, fmt.Errorf("%s: %w", name, err)
+	}
+	v.StartPos = start
+	v.EndPos = start + v.EncryptLenght
+	return v, nil
+}
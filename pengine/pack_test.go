@@ -0,0 +1,244 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The output of Pack must not depend on the number of workers. With Deterministic, it is byte-identical.
+func TestPackWorkersReproducible(t *testing.T) {
+	files := testFiles()
+	var first []byte
+	for _, workers := range []int{1, 2, 8} {
+		path, _ := packTest(t, files, PackOptions{Deterministic: true, Compression: CompressionGzip, Workers: workers})
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = data
+		} else if !bytes.Equal(data, first) {
+			t.Errorf("paket of %d workers is not the same as the paket of 1 worker", workers)
+		}
+	}
+}
+
+func TestPackPerEntryKeys(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{PerEntryKeys: true})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// the master key doesn't decrypt the files.
+	if _, ok, err := p.GetFile("a.txt", true, true); err == nil && ok {
+		t.Error("file of a PerEntryKeys paket is decrypted with the master key")
+	}
+	p.PerEntryKeys = true
+	checkFiles(t, p, files)
+}
+
+func TestPackPlain(t *testing.T) {
+	for _, mode := range []Mode{ModeCFB, ModeGCM} {
+		files := testFiles()
+		path, table := packTest(t, files, PackOptions{Mode: mode, Plain: []string{"*.txt", "license"}, MAC: true})
+		for name, v := range table {
+			plain := name == "a.txt" || name == "c.txt" || name == "license"
+			if v.Unencrypted != plain {
+				t.Errorf("Unencrypted of %s: %v, want %v", name, v.Unencrypted, plain)
+			}
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(data, files["a.txt"]) {
+			t.Error("plain file is not stored as it is")
+		}
+		if bytes.Contains(data, files["b.bin"]) {
+			t.Error("encrypted file is stored as it is")
+		}
+
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = mode
+		checkFiles(t, p, files)
+		mac := TableMAC(testKey, table)
+		if err := p.VerifyTableMAC(mac); err != nil {
+			t.Fatal(err)
+		}
+		// the flag is covered by the table MAC.
+		v := p.Table["b.bin"]
+		v.Unencrypted = true
+		p.Table["b.bin"] = v
+		if err := p.VerifyTableMAC(mac); err == nil {
+			t.Error("VerifyTableMAC doesn't detect a flipped Unencrypted flag")
+		}
+		p.Close()
+	}
+}
+
+func TestPackPlainBadPattern(t *testing.T) {
+	_, paths := writeTestFiles(t, testFiles())
+	if _, err := Pack(ioutil.Discard, testKey, paths, PackOptions{Plain: []string{"["}}); err == nil {
+		t.Error("Pack accepts a bad pattern")
+	}
+}
+
+// BenchmarkPack compares the sequential packer (1 worker) with the parallel one, on a folder of many files.
+func BenchmarkPack(b *testing.B) {
+	files := make(map[string][]byte, 64)
+	var size int64
+	for i := 0; i < 64; i++ {
+		content := make([]byte, 256<<10)
+		rand.Read(content)
+		files[fmt.Sprintf("file%02d", i)] = content
+		size += int64(len(content))
+	}
+	_, paths := writeTestFiles(b, files)
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{{"sequential", 1}, {"parallel", 0}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				if _, err := Pack(ioutil.Discard, testKey, paths, PackOptions{Hash: "sha256", Workers: bench.workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestPackFile(t *testing.T) {
+	files := testFiles()
+	_, paths := writeTestFiles(t, files)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "data.pack")
+
+	table, err := PackFile(out, testKey, paths, PackOptions{Sync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(testKey, out, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, p, files)
+	p.Close()
+	before, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed packing doesn't touch the old paket and leaves no temporary file.
+	if _, err := PackFile(out, testKey, append(paths, filepath.Join(dir, "missing")), PackOptions{}); err == nil {
+		t.Fatal("PackFile with a missing file succeeded")
+	}
+	after, err := ioutil.ReadFile(out)
+	if err != nil || !bytes.Equal(after, before) {
+		t.Error("failed PackFile changed the existing paket")
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files left in the folder: %d, want only the paket", len(entries))
+	}
+
+	if _, err := PackFile(filepath.Join(dir, "new.pack"), testKey, []string{filepath.Join(dir, "missing")}, PackOptions{}); err == nil {
+		t.Fatal("PackFile with a missing file succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.pack")); !os.IsNotExist(err) {
+		t.Errorf("failed PackFile created the paket: %v", err)
+	}
+
+	// without Sync, the paket is still replaced atomically.
+	newFiles := map[string][]byte{"d.txt": []byte("replaced")}
+	_, newPaths := writeTestFiles(t, newFiles)
+	table, err = PackFile(out, testKey, newPaths, PackOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = New(testKey, out, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, newFiles)
+}
+
+func TestAppend(t *testing.T) {
+	newData := bytes.Repeat([]byte("appended "), 300)
+	for _, opts := range []PackOptions{
+		{},
+		{Mode: ModeGCM, BindNames: true, MAC: true, Compression: CompressionGzip},
+		{PerEntryKeys: true, Hash: "sha512", Minimal: true},
+	} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		v, err := Append(path, testKey, table, "new.txt", newData, opts)
+		if err != nil {
+			t.Fatalf("Append with %+v: %v", opts, err)
+		}
+		table["new.txt"] = v
+		files["new.txt"] = newData
+
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		p.PerEntryKeys = opts.PerEntryKeys
+		if err := p.ValidateTable(); err != nil {
+			t.Error(err)
+		}
+		for name, want := range files {
+			got, _, err := p.GetFile(name, true, !opts.Minimal)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%+v: GetFile %s after Append: %v", opts, name, err)
+			}
+		}
+		if _, ok, err := p.GetFile("new.txt", true, true); !opts.Minimal && (err != nil || !ok) {
+			t.Errorf("%+v: hash of the appended file: %v, %v", opts, ok, err)
+		}
+		p.Close()
+	}
+}
+
+func TestAppendRefused(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{MAC: true})
+	if _, err := Append(path, testKey, table, "a.txt", []byte("x"), PackOptions{MAC: true}); err == nil {
+		t.Error("Append of an existing name succeeded")
+	}
+	// options that don't match the table.
+	for _, opts := range []PackOptions{{}, {MAC: true, Hash: "sha512"}, {MAC: true, Minimal: true}} {
+		if _, err := Append(path, testKey, table, "new.txt", []byte("x"), opts); err == nil {
+			t.Errorf("Append with %+v to a MAC paket succeeded", opts)
+		}
+	}
+
+	// self-describing pakets have the table in the header.
+	dataPath, dataTable := packTest(t, testFiles(), PackOptions{})
+	selfPath := writeSelfDescribing(t, dataPath, Header{Table: dataTable})
+	if _, err := Append(selfPath, testKey, dataTable, "new.txt", []byte("x"), PackOptions{}); err == nil {
+		t.Error("Append to a self-describing paket succeeded")
+	}
+}
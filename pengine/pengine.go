@@ -16,41 +16,127 @@ package pengine
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"path"
+	"sort"
 	"sync"
 )
 
 var (
-	// If there is no data in the map sent to New, the functions you use will return this error.
+	// New and the other functions creating a Paket return this error if the table is empty.
 	ErrMinimumMapValue = errors.New("map cannot be less than 1 in length")
+
+	// New returns this error if the paket file does not exist. It also matches os.ErrNotExist with errors.Is.
+	ErrPaketNotFound = fmt.Errorf("paket not found: %w", os.ErrNotExist)
+
+	// Functions taking a key return this error (with the length) if the key is not 16, 24 or 32 bytes long. See ValidKeyLength.
+	ErrKeyLength = errors.New("key must be 16, 24 or 32 length")
+
+	// New returns this error if the paket file is empty.
+	ErrEmptyPaket = errors.New("there is no data in the paket file")
+
+	// Functions reading a file return this error (wrapped with the file name) if the file is not in the table.
+	// Use errors.Is to check it.
+	ErrFileNotInTable = errors.New("file not found on map")
+
+	// ErrFileNotFound is the old name of ErrFileNotInTable.
+	ErrFileNotFound = ErrFileNotInTable
+
+	// Functions reading a file return this error instead of ErrFileNotInTable if the name is empty.
+	// An empty name is a bug of the caller, not a missing file.
+	ErrEmptyName = errors.New("file name is empty")
+
+	// It was returned by GetGoroutineSafe if the length of a file in the table was more than the total length of the paket.
+	// It is not returned anymore, data past the end of the paket returns an error wrapping io.ErrUnexpectedEOF.
+	ErrLengthExceeded = errors.New("more length than file size")
+
+	// Reading functions return this error after Close.
+	ErrClosed = errors.New("paket is closed")
+
+	// DecryptGCM returns this error if the data was modified or the key is wrong.
+	ErrIntegrity = errors.New("data is modified or the key is wrong")
+
+	// Decrypt returns this error if the data is shorter than IVSize. The table or the paket is corrupt.
+	ErrShortCiphertext = errors.New("encrypted data is shorter than the IV")
+
+	// GetFile returns this error in strict IV mode, if the IV of a file is all zero or used by another file.
+	ErrWeakIV = errors.New("weak IV")
+
+	// GetFile returns this error if the decrypted data is not OriginalLenght long. The table or the positions of the file are wrong.
+	ErrLengthMismatch = errors.New("decrypted length doesn't match the table")
 )
 
 // type declaration for map values.
+//
+// Positions and lengths are int64, so pakets larger than 2 GB work on 32-bit systems too.
+//
+// The JSON names of the fields are in the tags (see WriteTableJSON). OriginalLenght and EncryptLenght are written as originalLength and encryptLength.
 type Values struct {
 	// start position
-	StartPos int
+	StartPos int64 `json:"startPos"`
 
 	// end position
-	EndPos int
+	EndPos int64 `json:"endPos"`
 
 	// length of the original file.
-	OriginalLenght int
+	OriginalLenght int64 `json:"originalLength"`
 
 	// length of the encrypted data.
-	EncryptLenght int
+	EncryptLenght int64 `json:"encryptLength"`
+
+	// Hash of the original file (before compression and encryption).
+	// GetFile compares it with the hash of the decrypted data.
+	HashOriginal string `json:"hashOriginal,omitempty"`
+
+	// Hash of encrypted data, as it is stored in the paket.
+	// GetFile compares it with the hash of the read data when it doesn't decrypt. Verify uses it too.
+	HashEncrypt string `json:"hashEncrypt,omitempty"`
+
+	// Algorithm of HashOriginal and HashEncrypt (see HashByName). Empty means sha256.
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+
+	// Modification time of the original file, in unix nanoseconds. 0 if it is unknown.
+	// See also ExtractAllContext, which restores it.
+	ModTime int64 `json:"modTime,omitempty"`
+
+	// If true, the file was compressed with gzip before encryption (see Compress).
+	// GetFile decompresses it after decryption.
+	// OriginalLenght and HashOriginal are of the file before compression, EncryptLenght is of the compressed and encrypted data.
+	//
+	// It is kept for old tables. New tables use Compression.
+	Compressed bool `json:"compressed,omitempty"`
+
+	// Compression algorithm of the file. If it is CompressionNone, Compressed is used.
+	Compression Compression `json:"compression,omitempty"`
 
-	// Hash of the original file.
-	HashOriginal string
+	// CRC32 (Castagnoli) of the encrypted data (see CRC). 0 means there is no CRC.
+	// It is a fast check against corrupt data, used by GetFileCheck with CheckCRC.
+	CRC uint32 `json:"crc,omitempty"`
 
-	// Hash of encrypted data.
-	HashEncrypt string
+	// HMAC-SHA256 of the encrypted data with the key, as hex (see EntryMAC). Empty if the paket was created without MACs.
+	// Unlike the hashes, it can't be recalculated without the key. If it is set, GetFile checks it instead of the hash.
+	MAC string `json:"mac,omitempty"`
+
+	// If true, the name of the file is authenticated with the data (GCM additional data, see EncryptGCMAAD and PackOptions.BindNames).
+	// Data moved under another name fails with ErrIntegrity.
+	NameAAD bool `json:"nameAAD,omitempty"`
+
+	// Encrypted name of the file, as hex. Only in tables with encrypted names, where the name in the table is the NameID.
+	// See EncryptTableNames.
+	EncName string `json:"encName,omitempty"`
+
+	// If true, the file is stored without encryption (see Plain of PackOptions), it is not decrypted when it is read.
+	// It is for public files. The flag is covered by the table MAC, check the table with VerifyTableMAC so it can't be flipped.
+	Unencrypted bool `json:"unencrypted,omitempty"`
 }
 
 // type definition for the Paket.
@@ -66,6 +152,8 @@ type Datas map[string]Values
 //Used to generate a random key if the user has not specified a key. (for cmd tool)
 //
 // Returns error for the wrong size or  creating bytes.
+//
+// Not every length between 16 and 32 is a valid AES key. Use CreateAESKey to create keys.
 func CreateRandomBytes(l uint8) ([]byte, error) {
 	if l < 16 || l > 32 {
 		return nil, errors.New("minimum value for l is 16, maximum value for l is 32")
@@ -78,6 +166,36 @@ func CreateRandomBytes(l uint8) ([]byte, error) {
 	return res, nil
 }
 
+// CreateAESKey generates a random key that New and Encrypt accept.
+//
+// size is the key size in bits (128, 192 or 256) or in bytes (16, 24 or 32). Other values return an error.
+func CreateAESKey(size int) ([]byte, error) {
+	switch size {
+	case 128, 192, 256:
+		size /= 8
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid AES key size %d: use 128, 192 or 256 bits (16, 24 or 32 bytes)", size)
+	}
+	return CreateRandomBytes(uint8(size))
+}
+
+// ValidKeyLength returns an error wrapping ErrKeyLength if the key is not 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256).
+// New, NewCrypter and the other functions taking a key check it with this function.
+// Call it to check a key (e.g. from a config file) before creating anything.
+func ValidKeyLength(key []byte) error {
+	return validKeySize(len(key))
+}
+
+// validKeySize is ValidKeyLength for a length. DeriveKey checks the length of the key it creates with it.
+func validKeySize(size int) error {
+	switch size {
+	case 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("%w, it is %d", ErrKeyLength, size)
+}
+
 // Encrypt encrypts the data using the key.
 //
 // Uses the CFB mode.
@@ -89,22 +207,11 @@ func CreateRandomBytes(l uint8) ([]byte, error) {
 //
 //If everything is working correctly, it returns an encrypted bytes and nil error.
 func Encrypt(key, data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key[:])
+	c, err := NewCrypter(key)
 	if err != nil {
 		return nil, err
 	}
-
-	ciphertext := make([]byte, aes.BlockSize+len(data))
-	v := ciphertext[:aes.BlockSize]
-
-	_, rerr := io.ReadFull(rand.Reader, v)
-	if rerr != nil {
-		return nil, rerr
-	}
-
-	s := cipher.NewCFBEncrypter(block, v)
-	s.XORKeyStream(ciphertext[aes.BlockSize:], data)
-	return ciphertext, nil
+	return c.Encrypt(data)
 }
 
 // Decrypt decrypts the encrypted data with the key.
@@ -115,19 +222,78 @@ func Encrypt(key, data []byte) ([]byte, error) {
 // So you should compare it with the original data with a suitable hash function (see sha256, sha512 module...).
 // Otherwise, you can't be sure it is returning the correct data.
 //
+// data is not modified. The decrypted bytes are written to a new slice.
+//
+// Returns ErrShortCiphertext if data is shorter than IVSize.
+//
 // If everything is working correctly, it returns  decrypted bytes and nil error.
 func Decrypt(key, data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(key[:])
+	c, err := NewCrypter(key)
 	if err != nil {
 		return nil, err
 	}
-	iv := data[:aes.BlockSize]
-	data = data[aes.BlockSize:]
-	stream := cipher.NewCFBDecrypter(block, iv)
-	stream.XORKeyStream(data, data)
-	return data, nil
+	return c.Decrypt(data)
 }
 
+// EncryptGCM encrypts the data using the key.
+//
+// Uses the GCM mode. Unlike CFB, GCM is authenticated. DecryptGCM detects a wrong key or modified data.
+//
+// Key must be 16, 24 or 32 size.
+//
+// A random nonce (see GCMNonceSize) is prepended to the encrypted data. The authentication tag is appended.
+func EncryptGCM(key, data []byte) ([]byte, error) {
+	return EncryptGCMAAD(key, data, nil)
+}
+
+// EncryptGCMAAD encrypts the data like EncryptGCM, and authenticates aad (additional data) with it.
+// aad is not stored in the output, DecryptGCMAAD must be called with the same aad.
+//
+// Pack uses the name of the file as aad with BindNames, so the data of a file can't be moved under another name.
+func EncryptGCMAAD(key, data, aad []byte) ([]byte, error) {
+	c, err := NewCrypter(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.EncryptGCM(data, aad)
+}
+
+// DecryptGCM decrypts the data encrypted by EncryptGCM.
+//
+// Returns ErrIntegrity if the key is wrong or the data was modified.
+func DecryptGCM(key, data []byte) ([]byte, error) {
+	return DecryptGCMAAD(key, data, nil)
+}
+
+// DecryptGCMAAD decrypts the data encrypted by EncryptGCMAAD.
+//
+// Returns ErrIntegrity if the key is wrong, the data was modified or aad is not the same.
+func DecryptGCMAAD(key, data, aad []byte) ([]byte, error) {
+	c, err := NewCrypter(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.DecryptGCM(data, aad)
+}
+
+// IVSize is the length of the IV at the beginning of the data encrypted by Encrypt.
+// Every encrypted file is IVSize bytes longer than the original (see also GCMNonceSize).
+const IVSize = aes.BlockSize
+
+// GCMNonceSize is the length of the nonce at the beginning of the data encrypted by EncryptGCM.
+const GCMNonceSize = 12
+
+// Mode is the encryption mode of the files in a paket.
+type Mode int
+
+const (
+	// ModeCFB is the AES CFB mode (see Encrypt). Default mode and the mode of old pakets.
+	ModeCFB Mode = iota
+
+	// ModeGCM is the AES GCM mode (see EncryptGCM).
+	ModeGCM
+)
+
 // Paket that keeps the information of the file to be read.
 // It should be created with New.
 type Paket struct {
@@ -144,13 +310,94 @@ type Paket struct {
 	// Usually created by the cmd tool.
 	Table Datas
 
+	// Hash function for the files whose table entry has no HashAlgorithm. If nil, sha256 is used.
+	// Files with a HashAlgorithm always use that algorithm.
+	HashFunc HashFunc
+
+	// Encryption mode of the paket. New sets it to ModeCFB, set it to ModeGCM for pakets created with "-mode gcm".
+	Mode Mode
+
+	// Cipher of the files. If it is nil, the AES cipher of Mode is used (see Mode.Cipher).
+	// Set it for pakets created with a custom Cipher in PackOptions.
+	Cipher Cipher
+
+	// If StrictIV is true, GetFile returns ErrWeakIV for files whose IV is all zero or same as the IV of another file.
+	// Otherwise a warning is logged, once for every file.
+	// Encrypt never creates such IVs, so they point to a broken packer or a modified paket.
+	// Files with the same IV and the same encrypted data are not reported, deterministic packing (PackOptions.Deterministic)
+	// gives them to files with the same content and it reveals nothing more than the same encrypted data does.
+	StrictIV bool
+
+	// If WipeOnClose is true, Close overwrites the Key and the shared buffers with zeros (see Scrub).
+	// The slice given to New as key is wiped too, because Key is the same slice.
+	WipeOnClose bool
+
+	// Key of the table MAC and the file MACs (see VerifyTableMAC and EntryMAC). If it is nil, Key is used.
+	// With a separate MetaKey, users who have only Key can read the files but can't create a valid table.
+	MetaKey []byte
+
+	// If PerEntryKeys is true, every file is decrypted with its own key, derived from Key and the name of the file (see EntryKey).
+	// Set it for pakets created with PerEntryKeys in PackOptions (-entrykeys parameter of the cmd tool).
+	PerEntryKeys bool
+
+	// Key check value of the paket (see KeyCheckValue). Used by CheckKey.
+	// OpenSelfDescribing sets it from the header. For other pakets, set it to PaketKeyCheck of the table file.
+	KeyCheck []byte
+
+	// Size of the chunks read from the paket and decrypted by the streaming functions (OpenReader, WriteFileTo, File.WriteTo).
+	// Larger buffers mean fewer reads for big files, smaller ones less memory for many streams at the same time.
+	// 0 means DefaultBufferSize.
+	BufferSize int
+
 	//non-exported value created for access the file.
 	// This value is opened by New with filename parameter.
 	// file released with the Close function.
-	file *os.File
+	// It is an io.Closer, because NewMmap keeps a mapping here instead of a file.
+	file io.Closer
+
+	// The data of the paket is read through this value.
+	// For New it is the file, for NewFromReaderAt it is the given reader.
+	reader io.ReaderAt
+
+	// Protects reader against Close.
+	// Reads use ReadAt, which is safe for concurrent use, so GetFile only takes the read lock.
+	// Files requested at the same time are read in parallel.
+	globMut sync.RWMutex
+
+	// Incremented by Swap, so readers created before it stop working (see closeGuard). Protected by globMut.
+	gen uint64
 
-	// Used to prevent conflicts in GetFile. For files requested at the same time.
-	globMut sync.Mutex
+	// Protects Table against Swap. The functions of Paket read it with table().
+	tableMut sync.RWMutex
+
+	// True if the paket is created by NewMmap. Reopen maps it again.
+	mmapped bool
+
+	// Encrypted metadata blobs of the header. Set by OpenSelfDescribing. See GetMetadataBlob.
+	metadata map[string][]byte
+
+	// Folder of the local cache for decrypted files. Set by NewRemoteCached. Empty means no cache.
+	cacheDir string
+
+	// IVs seen by GetFile and the files they belong to, and the files already warned about by checkIV. Protected by ivMut.
+	ivs      map[string]ivOwner
+	ivWarned map[string]bool
+	ivMut    sync.Mutex
+
+	// Buffers given by GetShared that are still in use. Protected by sharedMut.
+	shared    map[string]*SharedBuffer
+	sharedMut sync.Mutex
+
+	// True after Scrub, the key is wiped. Written with globMut and sharedMut held, so it can be read with one of them.
+	scrubbed bool
+
+	// True after DisableFinalizer, the files opened by Reopen and Swap don't get a finalizer either. Protected by globMut.
+	noFinalizer bool
+
+	// Crypter of Key, so GetFile doesn't calculate the key schedule for every file. See keyCrypter.
+	crypter    *Crypter
+	crypterKey []byte
+	crypterMut sync.Mutex
 }
 
 // New Creates a new Package method.
@@ -158,65 +405,192 @@ type Paket struct {
 //
 // key parameter refers to the encryption key. It must be 16, 24 or 32 length. Returns nil and error for keys of incorrect length.
 //
-// Panic occurs if the specified file does not exist.
+// Returns ErrPaketNotFound if the specified file does not exist, ErrEmptyPaket if it is empty.
 //
 // table parameter is defined in go file created by the cmd tool.
-// There must be a minimum of 1 file in the table.
+// There must be a minimum of 1 file in the table, ErrMinimumMapValue is returned for an empty table.
 //
 // After getting all the data you need, should be terminated with  Close.
+//
+// New doesn't check the reader version. The table is compiled with this package, so it can't have fields this package doesn't know,
+// but the settings outside the table (PerEntryKeys, encrypted names) can need a newer reader.
+// Use Open with PaketMinReaderVersion of the table file as MinReaderVersion of OpenOptions to check it.
 func New(key []byte, paketFileName string, table Datas) (*Paket, error) {
-	l := len(key)
-	if l == 16 || l == 24 || l == 32 {
-		if !Exists(paketFileName) {
-			panic(paketFileName + " paket not found.")
+	if err := ValidKeyLength(key); err != nil {
+		return nil, err
+	}
+	if len(table) == 0 {
+		return nil, ErrMinimumMapValue
+	}
+	f, err := os.Open(paketFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, paketFileName)
 		}
+		return nil, err
+	}
 
-		f, err := os.Open(paketFileName)
-		if err != nil {
-			return nil, err
-		}
+	fInfo, ferr := f.Stat()
+	if ferr != nil {
+		f.Close()
+		return nil, ferr
+	}
 
-		fInfo, ferr := f.Stat()
-		if ferr != nil {
-			return nil, err
-		}
+	if fInfo.Size() > 0 {
+		return &Paket{file: guardFile(f, paketFileName), reader: f, Table: table, Key: key, paketFileName: paketFileName}, nil
+	}
+	f.Close()
+	return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, paketFileName)
+}
 
-		if fInfo.Size() > 0 {
-			return &Paket{file: f, Table: table, Key: key, paketFileName: paketFileName}, nil
-		}
-		perr := "there is no data in the file: " + f.Name()
-		panic(perr)
+// NewFromReaderAt creates a new Paket that reads its data from r instead of a file on disk.
+//
+// key and table parameters are the same as New.
+//
+// r is not closed by Close. If it needs to be closed, the caller should do it after using the Paket.
+//
+// r must be safe for concurrent ReadAt calls (like *os.File and *bytes.Reader), because files requested
+// at the same time are read in parallel.
+func NewFromReaderAt(key []byte, r io.ReaderAt, table Datas) (*Paket, error) {
+	if err := ValidKeyLength(key); err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, errors.New("reader cannot be nil")
+	}
+	if len(table) == 0 {
+		return nil, ErrMinimumMapValue
 	}
-	return nil, errors.New("key must be 16, 24 or 32 length")
+	return &Paket{reader: r, Table: table, Key: key}, nil
 }
 
-// GetFile Returns the content of the requested file.
+// NewFromBytes creates a new Paket from paket data in memory.
+// It is for pakets embedded into the program with go:embed, so no paket file is needed on disk:
+//
+//	//go:embed data.pack
+//	var data []byte
+//
+//	p, err := pengine.NewFromBytes(key, data, PaketData)
 //
-// If the file cannot be found in the map and the length cannot be read, a panic occurs.
+// data must not be modified while the Paket is used. key and table parameters are the same as New.
+func NewFromBytes(key []byte, data []byte, table Datas) (*Paket, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyPaket
+	}
+	return NewFromReaderAt(key, bytes.NewReader(data), table)
+}
+
+// GetFile Returns the content of the requested file.
 //
-// All errors except these errors return with error.
+// If the file cannot be found in the map, the error wraps ErrFileNotInTable.
 //
 // If decrypt is true, it is decrypted. If not, encrypted bytes are returned.
 //
-// If value of shaControl is true, the hash of the decrypted data is compared with hash of the original file.
+// If value of shaControl is true, the hash of the decrypted data is compared with hash of the original file (HashOriginal).
 //
-// If decrypt is false and shaControl is true, the hash of the encrypted file in the table is compared with the encrypted hash of the read file.
+// If decrypt is false and shaControl is true, the hash of the encrypted file in the table (HashEncrypt) is compared with the encrypted hash of the read file.
 //
 // If the hash comparison is true, the second value is set to true.
 //
 // If hashControl is false, checks are skipped. Returns False.
 //
+// Tables created with the -m parameter of the cmd tool have no hashes. For them the hash comparison always returns false.
+//
+// If the file has a MAC in the table (see EntryMAC) and shaControl is true, the MAC is checked instead of the hash.
+// A wrong MAC returns an error wrapping ErrIntegrity, a right one sets the second value to true.
+//
+// GetFile is safe for concurrent use. Files requested at the same time are read in parallel.
+//
 // Both values do not have to be true. However, it may be good to generate a control mechanism like hash with your own work.
 // The decrypt (bool) value has been added for convenience. As a recommendation,
 // it is better to pass both values to true to this function.
 func (p *Paket) GetFile(filename string, decrypt, shaControl bool) ([]byte, bool, error) {
-	file, found := p.Table[filename]
+	return p.GetFileContext(context.Background(), filename, decrypt, shaControl)
+}
+
+// GetFileContext is GetFile with cancellation.
+//
+// ctx is checked before reading and between the chunks (see ReadChunkSize) of large files.
+// If ctx is cancelled, ctx.Err() is returned.
+func (p *Paket) GetFileContext(ctx context.Context, filename string, decrypt, shaControl bool) ([]byte, bool, error) {
+	res, err := p.getFile(ctx, filename, decrypt, shaControl)
+	if err != nil {
+		return nil, false, err
+	}
+	return res.Data, res.HashOK, nil
+}
+
+// FileResult is the result of GetFileResult.
+type FileResult struct {
+	// Content of the file. Decrypted or encrypted, as requested.
+	Data []byte
+
+	// True if the hash (or the MAC) of the file was checked.
+	// It is false if shaControl was false or the table has no hash for the file (tables created with -m).
+	HashChecked bool
+
+	// True if the hash (or the MAC) was checked and it matched. Always false if HashChecked is false.
+	HashOK bool
+}
+
+// GetFileResult is GetFile with a FileResult. Parameters are the same as GetFile.
+//
+// The second value of GetFile is false both when the hash doesn't match and when it wasn't checked.
+// FileResult tells them apart with HashChecked.
+func (p *Paket) GetFileResult(filename string, decrypt, shaControl bool) (*FileResult, error) {
+	return p.getFile(context.Background(), filename, decrypt, shaControl)
+}
+
+// GetFilePartial is GetFile that also returns the number of bytes of the encrypted data read from the paket.
+// The number is returned with errors too, so a truncated or unreadable paket can be diagnosed:
+//
+//	data, ok, n, err := p.GetFilePartial(name, true, true)
+//	if err != nil {
+//		v, _ := p.Stat(name)
+//		log.Printf("read %d of %d bytes of %s: %v", n, v.EncryptLenght, name, err)
+//	}
+//
+// The data is nil on errors, like GetFile. If the file is read completely, n is EncryptLenght,
+// also when decrypting or the hash check fails after it. Files from the cache of NewRemoteCached return their size in the cache.
+func (p *Paket) GetFilePartial(filename string, decrypt, shaControl bool) ([]byte, bool, int64, error) {
+	res, n, err := p.readFile(context.Background(), filename, decrypt, shaControl)
+	if err != nil {
+		return nil, false, n, err
+	}
+	return res.Data, res.HashOK, n, nil
+}
+
+// getFile reads the file for GetFileContext and GetFileResult.
+func (p *Paket) getFile(ctx context.Context, filename string, decrypt, shaControl bool) (*FileResult, error) {
+	res, _, err := p.readFile(ctx, filename, decrypt, shaControl)
+	return res, err
+}
+
+// readFile is getFile with the number of bytes read from the paket, for GetFilePartial.
+func (p *Paket) readFile(ctx context.Context, filename string, decrypt, shaControl bool) (*FileResult, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	// the table is read with the lock, so Swap can't change it until the file is read.
+	p.globMut.RLock()
+	defer p.globMut.RUnlock()
+
+	// checked before the cache, a closed Paket doesn't serve cached files either.
+	if p.reader == nil {
+		return nil, 0, ErrClosed
+	}
+
+	file, found := p.table()[filename]
 	if !found {
-		return nil, false, errors.New("File not found on map: " + filename)
+		return nil, 0, notInTable(filename)
 	}
 
-	p.globMut.Lock()
-	defer p.globMut.Unlock()
+	if decrypt && p.cacheDir != "" {
+		if data, ok := p.readCache(filename, file); ok {
+			// the cache is always validated with the hash. Only verified files are written to it, see below.
+			return &FileResult{Data: data, HashChecked: shaControl, HashOK: shaControl}, int64(len(data)), nil
+		}
+	}
 
 	// We need the length of the encrypted data to be able to load to memory the file
 	length := file.EncryptLenght
@@ -225,77 +599,361 @@ func (p *Paket) GetFile(filename string, decrypt, shaControl bool) ([]byte, bool
 
 	content := make([]byte, length)
 
-	// We go to the position of file
-	_, err := p.file.Seek(int64(start), 0)
+	// We read from the position of file up to the position where the encrypted data ends. We Alocated the *content* variable
+	// io.ReadFull keeps reading until content is full, a single Read can return less.
+	// Large files are read in chunks, so a cancelled ctx doesn't wait for the whole file.
+	section := io.NewSectionReader(p.reader, start, length)
+	var n int64
+	for off := int64(0); off < length; off += ReadChunkSize {
+		if off > 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, n, err
+			}
+		}
+		end := off + ReadChunkSize
+		if end > length {
+			end = length
+		}
+		m, rerr := io.ReadFull(section, content[off:end])
+		n += int64(m)
+		if rerr != nil {
+			return nil, n, regionError(filename, start, length, rerr)
+		}
+	}
+	// unencrypted files have no IV.
+	if !file.Unencrypted {
+		if err := p.checkIV(filename, content); err != nil {
+			return nil, n, err
+		}
+	}
+	// A verified MAC is stronger than the hash, the hash is not compared then.
+	macOK := false
+	if shaControl && file.MAC != "" {
+		if err := p.checkMAC(filename, file, content); err != nil {
+			return nil, n, err
+		}
+		macOK = true
+	}
+
+	res := &FileResult{Data: content}
+	wantHash := file.HashEncrypt
+	if decrypt {
+		decryptedData, err := p.decrypt(filename, file, content)
+		if err != nil {
+			return nil, n, err
+		}
+		// cache hits are not checked with the MAC, so only files with a valid MAC are cached.
+		// writeCache checks the hash.
+		if p.cacheDir != "" && (file.MAC == "" || macOK || p.checkMAC(filename, file, content) == nil) {
+			p.writeCache(filename, file, decryptedData)
+		}
+		res.Data = decryptedData
+		wantHash = file.HashOriginal
+	}
+	switch {
+	case !shaControl:
+	case macOK:
+		res.HashChecked, res.HashOK = true, true
+	case wantHash != "":
+		res.HashChecked = true
+		res.HashOK = HashEqual(p.hash(file, res.Data), wantHash)
+	}
+	return res, n, nil
+}
+
+// ReadChunkSize is the size of the chunks GetFileContext reads between the checks of the context.
+const ReadChunkSize = 1 << 20
+
+// GetFileIfChanged returns the content of the file only if it is different from what the caller already has.
+//
+// knownHash is the HashOriginal value the caller got before (for example the ETag sent by an HTTP client).
+// If it is the same as HashOriginal in the table, nothing is read and (nil, false, nil) is returned.
+// Otherwise the content is returned like GetFile without hash checking, and the second value is true.
+//
+// Files without HashOriginal in the table are always returned.
+func (p *Paket) GetFileIfChanged(name, knownHash string, decrypt bool) ([]byte, bool, error) {
+	file, found := p.table()[name]
+	if !found {
+		return nil, false, notInTable(name)
+	}
+	// knownHash comes from the client, so it is compared in constant time.
+	if file.HashOriginal != "" && HashEqual(knownHash, file.HashOriginal) {
+		return nil, false, nil
+	}
+	content, _, err := p.GetFile(name, decrypt, false)
 	if err != nil {
 		return nil, false, err
 	}
-	// We read it to the position we want. So in this case, up to the position  where the encrypted data ends. We Alocated the *content* variable
-	_, rerr := p.file.Read(content)
-	if rerr != nil {
-		return nil, false, rerr
+	return content, true, nil
+}
+
+// Glob returns the sorted names of the files in the table matching the pattern.
+// The pattern syntax is the same as path.Match, e.g. "textures/*.png".
+//
+// Returns path.ErrBadPattern for a malformed pattern.
+func (p *Paket) Glob(pattern string) ([]string, error) {
+	// checked before the loop, so an empty table doesn't hide a bad pattern.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
 	}
-	switch decrypt {
-	case true:
-		decryptedData, err := Decrypt(p.Key, content)
+	matches := []string{}
+	for name := range p.table() {
+		matched, err := path.Match(pattern, name)
 		if err != nil {
-			return nil, false, err
+			return nil, err
 		}
-		if shaControl {
-			decryptedHash := []byte(fmt.Sprintf("%x", sha256.Sum256(decryptedData)))
-			encryptedHash := []byte(file.HashEncrypt)
-			return decryptedData, bytes.Equal(decryptedHash, encryptedHash), nil
+		if matched {
+			matches = append(matches, name)
 		}
-		return decryptedData, false, nil
-	case false:
-		if shaControl {
-			forgSha := []byte(file.HashEncrypt)
-			corgSha := []byte(fmt.Sprintf("%x", sha256.Sum256(content)))
-			return content, bytes.Equal(corgSha, forgSha), nil
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// RawEncrypted returns the stored encrypted data of the file without decrypting it.
+// iv is the first block of the data (see Encrypt), or the nonce for ModeGCM. ciphertext is the rest.
+// For a custom Cipher the layout is not known, iv is empty and ciphertext is all the data.
+//
+// It is for moving encrypted files to other systems that keep their own IVs.
+//
+// Returns ErrFileNotInTable if the file is not in the table.
+func (p *Paket) RawEncrypted(name string) (iv, ciphertext []byte, err error) {
+	file, section, err := p.section(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	ivSize := p.ivSize()
+	if file.EncryptLenght < int64(ivSize) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrShortCiphertext, name)
+	}
+	content := make([]byte, file.EncryptLenght)
+	if _, err := io.ReadFull(section, content); err != nil {
+		if err == ErrClosed {
+			return nil, nil, err
 		}
-		return content, false, nil
-	default:
-		return content, false, nil
+		return nil, nil, regionError(name, file.StartPos, file.EncryptLenght, err)
 	}
+	return content[:ivSize], content[ivSize:], nil
 }
 
-// GetGoroutineSafe created to securely retrieve data when using with multiple goroutines.
-// In any case, it only returns decrypted data.
+// RawSection returns a SectionReader over the stored encrypted data of the file (IV included), nothing is read or decrypted.
 //
-// It does not do any hash checking.
-func (p *Paket) GetGoroutineSafe(name string) ([]byte, error) {
-	file, found := p.Table[name]
+// Works with all constructors. Reads after Close return ErrClosed.
+//
+// Returns ErrFileNotInTable if the file is not in the table.
+func (p *Paket) RawSection(filename string) (*io.SectionReader, error) {
+	_, section, err := p.section(filename)
+	return section, err
+}
+
+// section returns the table information of the file and a SectionReader over its encrypted data.
+// Both are taken with the lock, so they belong to the same paket even if Swap runs at the same time.
+func (p *Paket) section(filename string) (Values, *io.SectionReader, error) {
+	p.globMut.RLock()
+	defer p.globMut.RUnlock()
+	file, found := p.table()[filename]
 	if !found {
-		return nil, errors.New("File not found on map: " + name)
+		return Values{}, nil, notInTable(filename)
 	}
-	length := file.EncryptLenght
-	encryptedLenght, _ := p.GetLen()
-	if length > encryptedLenght[1] {
-		return nil, errors.New("more length than file size")
+	if p.reader == nil {
+		return Values{}, nil, ErrClosed
 	}
-	start := file.StartPos
+	return file, io.NewSectionReader(closeGuard{p: p, gen: p.gen}, file.StartPos, file.EncryptLenght), nil
+}
 
-	f, err := os.Open(p.paketFileName)
-	if err != nil {
-		return nil, err
+// closeGuard reads from the reader of the Paket with the read lock of globMut.
+// Readers that live longer than a call (OpenReader, RawSection) use it, so Close waits for their reads in progress
+// and their reads after Close return ErrClosed instead of reading a closed file.
+// Reads after Swap return ErrClosed too, the positions of the reader belong to the old paket.
+type closeGuard struct {
+	p *Paket
+	// gen of the Paket when the guard was created.
+	gen uint64
+}
+
+func (g closeGuard) ReadAt(b []byte, off int64) (int, error) {
+	g.p.globMut.RLock()
+	defer g.p.globMut.RUnlock()
+	if g.p.reader == nil || g.p.gen != g.gen {
+		return 0, ErrClosed
 	}
-	defer f.Close()
+	return g.p.reader.ReadAt(b, off)
+}
 
-	if _, err := f.Seek(int64(start), 0); err != nil {
-		return nil, err
+// table returns the table of the Paket. Swap can replace it, so it is read with tableMut.
+func (p *Paket) table() Datas {
+	p.tableMut.RLock()
+	defer p.tableMut.RUnlock()
+	return p.Table
+}
+
+// decrypt decrypts the data of a file with the Cipher (or the mode) of the Paket and the key of the file.
+// Compressed files are also decompressed.
+// Returns an error wrapping ErrLengthMismatch if the result is not OriginalLenght long.
+func (p *Paket) decrypt(filename string, file Values, content []byte) ([]byte, error) {
+	c := p.cipher()
+	if c == nil {
+		return nil, fmt.Errorf("unknown mode %d", p.Mode)
 	}
-	content := make([]byte, length)
-	if _, err := f.Read(content); err != nil {
-		return nil, err
+	if _, ok := c.(AESGCM); file.NameAAD && !ok {
+		return nil, fmt.Errorf("%s is bound to its name, it needs ModeGCM", filename)
 	}
-	decryptedData, err := Decrypt(p.Key, content)
-	if err != nil {
-		content = nil // I don't understand what the gc of Go does sometimes. A guarantee
-		return nil, err
+	var decryptedData []byte
+	if file.Unencrypted {
+		// stored as it is, see Plain of PackOptions.
+		decryptedData = content
+	} else {
+		switch c.(type) {
+		case AESCFB, AESGCM:
+			// the AES ciphers use the Crypter, the key schedule is not calculated again for every file.
+			cr, err := p.fileCrypter(filename)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case file.NameAAD:
+				decryptedData, err = cr.DecryptGCM(content, []byte(filename))
+			case p.isCFB():
+				decryptedData, err = cr.Decrypt(content)
+			default:
+				decryptedData, err = cr.DecryptGCM(content, nil)
+			}
+			if err != nil {
+				return nil, err
+			}
+		default:
+			key, err := p.fileKey(filename)
+			if err != nil {
+				return nil, err
+			}
+			if decryptedData, err = c.Decrypt(key, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var err error
+	data := decryptedData
+	if compression := file.compression(); compression != CompressionNone {
+		// limited to the length in the table, so a crafted file can't fill the memory before the length check.
+		data, err = DecompressLimit(compression, decryptedData, file.OriginalLenght)
+		// the compressed plaintext is not returned, so it is wiped here.
+		WipeKey(decryptedData)
+		if errors.Is(err, ErrDecompressedTooLarge) {
+			return nil, fmt.Errorf("%w: %s is larger than %d bytes, the length in the table", ErrLengthMismatch, filename, file.OriginalLenght)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// a cheap check before the hash. Tables without OriginalLenght (0) are not checked.
+	if file.OriginalLenght != 0 && int64(len(data)) != file.OriginalLenght {
+		return nil, fmt.Errorf("%w: %s is %d bytes, table says %d", ErrLengthMismatch, filename, len(data), file.OriginalLenght)
+	}
+	return data, nil
+}
+
+// compression returns the compression of the file, also for old tables with Compressed.
+func (v Values) compression() Compression {
+	if v.Compression == CompressionNone && v.Compressed {
+		return CompressionGzip
+	}
+	return v.Compression
+}
+
+// ivSize returns the length of the IV (or nonce) at the beginning of the encrypted data.
+// It is 0 for custom ciphers, their layout is not known.
+func (p *Paket) ivSize() int {
+	switch p.cipher().(type) {
+	case AESCFB:
+		return IVSize
+	case AESGCM:
+		return GCMNonceSize
+	}
+	return 0
+}
+
+// notInTable returns the error of a file that is not in the table: ErrEmptyName for an empty name, ErrFileNotInTable otherwise.
+func notInTable(filename string) error {
+	if filename == "" {
+		return ErrEmptyName
+	}
+	return fmt.Errorf("%w: %s", ErrFileNotInTable, filename)
+}
+
+// regionError makes the error of reading the encrypted data of a file more clear.
+// If the paket file ended before the data, the returned error wraps io.ErrUnexpectedEOF.
+func regionError(filename string, start, length int64, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: data of %s (%d-%d) extends past the end of the paket", io.ErrUnexpectedEOF, filename, start, start+length)
+	}
+	return err
+}
+
+// ivOwner is the first file seen with an IV, and the sha256 of its encrypted data.
+type ivOwner struct {
+	name string
+	sum  [sha256.Size]byte
+}
+
+// checkIV looks at the IV (first block) of the encrypted data of a file. The encrypted data can be given in parts,
+// the first one starts with the IV.
+// An all-zero IV or an IV used by another file is reported as a warning, or as ErrWeakIV in strict mode.
+// The same IV with the same encrypted data is the same file packed deterministically, it is not reported.
+func (p *Paket) checkIV(filename string, content ...[]byte) error {
+	ivSize := p.ivSize()
+	if ivSize == 0 || len(content) == 0 || len(content[0]) < ivSize {
+		return nil
+	}
+	iv := string(content[0][:ivSize])
+
+	p.ivMut.Lock()
+	defer p.ivMut.Unlock()
+	if owner, seen := p.ivs[iv]; seen && owner.name == filename {
+		return nil
+	}
+
+	var sum [sha256.Size]byte
+	h := sha256.New()
+	for _, part := range content {
+		h.Write(part)
+	}
+	h.Sum(sum[:0])
+	problem := ""
+	if iv == string(make([]byte, ivSize)) {
+		problem = "IV of " + filename + " is all zero"
+	} else if owner, seen := p.ivs[iv]; seen && owner.sum != sum {
+		problem = "IV of " + filename + " is the same as the IV of " + owner.name
+	}
+	if problem != "" {
+		if p.StrictIV {
+			return fmt.Errorf("%w: %s", ErrWeakIV, problem)
+		}
+		if !p.ivWarned[filename] {
+			if p.ivWarned == nil {
+				p.ivWarned = make(map[string]bool)
+			}
+			p.ivWarned[filename] = true
+			log.Printf("pengine: warning: %s", problem)
+		}
 	}
 
-	content = nil // I don't understand what the gc of Go does sometimes. A guarantee
-	return decryptedData, nil
+	if p.ivs == nil {
+		p.ivs = make(map[string]ivOwner)
+	}
+	if _, seen := p.ivs[iv]; !seen {
+		p.ivs[iv] = ivOwner{name: filename, sum: sum}
+	}
+	return nil
+}
+
+// GetGoroutineSafe returns the decrypted content of the file, without hash checking.
+// It is the same as GetFile(name, true, false).
+//
+// Deprecated: GetFile is safe for concurrent use too, all reading functions share the file (or the mapping) of the Paket
+// and read it with ReadAt. Use GetFile.
+func (p *Paket) GetGoroutineSafe(name string) ([]byte, error) {
+	data, _, err := p.GetFile(name, true, false)
+	return data, err
 }
 
 // GetLen Returns the original and encrypted lengths of all files contained in Paket.
@@ -305,43 +963,270 @@ func (p *Paket) GetGoroutineSafe(name string) ([]byte, error) {
 // Normally values should be in bytes.
 //
 // returns an error if length is less than 1(see ErrMinimumMapValue). This case, other  things are 0.
+//
+// On 32-bit systems the sums overflow for pakets larger than 2 GB. Use GetLen64 for them.
 func (p *Paket) GetLen() ([2]int, error) {
-	values := [2]int{}
-	if len(p.Table) < 1 {
+	values, err := p.GetLen64()
+	return [2]int{int(values[0]), int(values[1])}, err
+}
+
+// GetLen64 is the same as GetLen, but the sums are int64. They don't overflow on 32-bit systems.
+func (p *Paket) GetLen64() ([2]int64, error) {
+	values := [2]int64{}
+	table := p.table()
+	if len(table) < 1 {
 		return values, ErrMinimumMapValue
 	}
-	for _, value := range p.Table {
+	for _, value := range table {
 		values[0] += value.OriginalLenght
 		values[1] += value.EncryptLenght
 	}
 	return values, nil
 }
 
+// Keys returns the names of all files in the Paket, sorted.
+// Its length is Count.
+func (p *Paket) Keys() []string {
+	return sortedNames(p.table())
+}
+
+// NamesSorted returns the names of all files in the Paket, in lexical order (sort.Strings, byte-wise).
+// It is the same as Keys. The index of a name in it is the index of GetByIndex.
+//
+// The order only depends on the names, so the index of a file doesn't change between calls while the table is the same.
+// Names with numbers should have the same length ("001", "002"... "999"), otherwise "10" is before "9".
+func (p *Paket) NamesSorted() []string {
+	return sortedNames(p.table())
+}
+
+// GetByIndex returns the ith file of NamesSorted. The other parameters and the results are the same as GetFile.
+//
+// It sorts the names for every call. For iterating all files, call NamesSorted once and GetFile with the names.
+// If i is out of range, the error wraps ErrFileNotInTable.
+func (p *Paket) GetByIndex(i int, decrypt, shaControl bool) ([]byte, bool, error) {
+	names := p.NamesSorted()
+	if i < 0 || i >= len(names) {
+		return nil, false, fmt.Errorf("%w: index %d, the paket has %d files", ErrFileNotInTable, i, len(names))
+	}
+	return p.GetFile(names[i], decrypt, shaControl)
+}
+
+// sortedNames returns the names of the table in lexical order.
+func sortedNames(table Datas) []string {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SkipEntry can be returned by the function of Walk to go on with the next file.
+// It is not returned by Walk. It is like filepath.SkipDir.
+var SkipEntry = errors.New("skip this entry")
+
+// Walk calls fn for every file in the Paket, in sorted order. Only the table is used, the files are not read.
+// fn can read the file itself, e.g. with OpenReader, so files are processed one by one.
+//
+// If fn returns an error, Walk stops and returns it. SkipEntry is not an error, the walk goes on with the next file.
+func (p *Paket) Walk(fn func(name string, v Values) error) error {
+	table := p.table()
+	for _, name := range sortedNames(table) {
+		if err := fn(name, table[name]); err != nil && err != SkipEntry {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of files in the Paket.
+func (p *Paket) Count() int {
+	return len(p.table())
+}
+
+// Stat returns the table information of the file. The second value is false if the file is not in the Paket.
+func (p *Paket) Stat(name string) (Values, bool) {
+	value, found := p.table()[name]
+	return value, found
+}
+
 // Close Closes the opened file (see Paket.file (non-exported)).
 //
-// Use this function when all your transactions are done (so you shouldn't use it with defer or something like that).
-// Otherwise, you must create a new Paket method.
+// Use this function when all your transactions are done. After it, you must create a new Paket method or call Reopen.
+//
+// Close waits for the reads in progress in other goroutines, so it is safe to call while files are read.
+// When you call Close, you cannot access the Package again. Reading functions (also the readers of OpenReader and RawSection) return ErrClosed after it.
+//
+// Calling Close more than once is safe, the next calls do nothing.
 //
-// When you call Close, you cannot access the Package again.
+// If WipeOnClose is true, the Key and the shared buffers are wiped (see Scrub).
 //
 // Returns error for unsuccessful events.
 func (p *Paket) Close() error {
-	err := p.file.Close()
-	if err != nil {
-		return nil
+	p.globMut.Lock()
+	defer p.globMut.Unlock()
+
+	var err error
+	if p.file != nil {
+		err = p.file.Close()
+		p.file = nil
+	}
+	p.reader = nil
+	if p.WipeOnClose {
+		p.scrub()
 	}
 	return err
 }
 
+// Reopen opens the paket file again after Close, with the same Key and Table.
+// A long-running program can close the paket, let the file be replaced, and continue with Reopen.
+// Pakets created with NewMmap are mapped again.
+//
+// It works only for pakets created from a file name (New and NewMmap), without WipeOnClose and Scrub.
+// Returns ErrPaketNotFound if the file doesn't exist anymore. If the paket is not closed, it does nothing.
+func (p *Paket) Reopen() error {
+	p.globMut.Lock()
+	defer p.globMut.Unlock()
+	if p.reader != nil {
+		return nil
+	}
+	if p.paketFileName == "" {
+		return errors.New("paket has no file name, it can't be reopened")
+	}
+	if p.WipeOnClose || p.scrubbed {
+		return errors.New("the key is wiped by Close or Scrub, the paket can't be reopened")
+	}
+
+	var np *Paket
+	var err error
+	if p.mmapped {
+		np, err = NewMmap(p.Key, p.paketFileName, p.Table)
+	} else {
+		np, err = New(p.Key, p.paketFileName, p.Table)
+	}
+	if err != nil {
+		return err
+	}
+	p.file, p.reader = np.file, np.reader
+	p.unguard()
+
+	// the file can be a new one, its IVs are not the same.
+	p.ivMut.Lock()
+	p.ivs, p.ivWarned = nil, nil
+	p.ivMut.Unlock()
+	return nil
+}
+
+// Swap replaces the paket file and the table with new ones, for updating the files of a running program without a restart.
+// Other parts of the program can keep their *Paket, the calls after Swap read the new file.
+//
+// The new file is opened like New (with mmap for pakets created with NewMmap) and checked with ValidateTable.
+// If it fails, the error is returned and the old file and table are kept.
+// Then Swap waits for the reads in progress, replaces the file and the table and closes the old file.
+// Key, Mode and the other settings are kept.
+//
+// Readers created before Swap (OpenReader, RawSection, File) return ErrClosed, their positions belong to the old paket.
+// Don't read the Table field directly while Swap can run, use Stat, Keys and the other functions.
+//
+// Swap works only for pakets created with New and NewMmap (and Open). Other pakets (OpenSelfDescribing, NewVolumes, NewFromZip,
+// NewHTTP...) return an error, their data is not at the beginning of a single local file.
+//
+// Returns ErrClosed if the Paket is closed.
+func (p *Paket) Swap(newPaketFile string, newTable Datas) error {
+	p.globMut.RLock()
+	oldName := p.paketFileName
+	p.globMut.RUnlock()
+	if oldName == "" {
+		return errors.New("paket is not created from a file name with New or NewMmap, it can't be swapped")
+	}
+
+	var np *Paket
+	var err error
+	if p.mmapped {
+		np, err = NewMmap(p.Key, newPaketFile, newTable)
+	} else {
+		np, err = New(p.Key, newPaketFile, newTable)
+	}
+	if err != nil {
+		return err
+	}
+	if err := np.ValidateTable(); err != nil {
+		np.Close()
+		return err
+	}
+
+	p.globMut.Lock()
+	if p.reader == nil {
+		p.globMut.Unlock()
+		np.Close()
+		return ErrClosed
+	}
+	old := p.file
+	p.file, p.reader = np.file, np.reader
+	p.unguard()
+	p.paketFileName = newPaketFile
+	p.gen++
+	p.tableMut.Lock()
+	p.Table = newTable
+	p.tableMut.Unlock()
+	p.globMut.Unlock()
+
+	p.ivMut.Lock()
+	p.ivs, p.ivWarned = nil, nil
+	p.ivMut.Unlock()
+	// buffers in use keep their content, new GetShared calls read the new file.
+	p.sharedMut.Lock()
+	p.shared = nil
+	p.sharedMut.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// HashEqual compares two hash strings in constant time.
+//
+// The hashes of public content are not secret, so bytes.Equal would be enough for them.
+// But anything that authenticates data (key fingerprints, MACs, signatures) must not leak
+// how many leading bytes match. Use this function for all of them.
+//
+// The comparisons that need it in this package:
+//   - the table MAC, the entry MACs and the key check value (hmac.Equal, the same thing for bytes).
+//     The attacker controls the paket, a timing leak would help to forge them byte by byte.
+//   - the hashes of GetFile, Verify, Diff, the cache and the self test of Pack. The hash in the table is
+//     compared with the hash of data an attacker may have changed.
+//   - the checksum of OpenOptions, the names of EncryptNames and the known hash of GetFileIfChanged,
+//     they come from outside and are compared with secret-derived or authenticated values.
+//   - the key of the Crypter cache, it is the key itself.
+//
+// The comparisons of the self test on the written bytes and the checks of the options don't involve secrets
+// and use bytes.Equal and ==.
+//
+// Strings of different lengths are not equal.
+func HashEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
 // a guarantee about the existence of file.
 //
-// Source: https://stackoverflow.com/a/12527546/13431469
-// Thanks to SO user.
+// Returns false also if the file can't be checked, e.g. the folder can't be read (permission denied).
+// Use FileExists to get the error of such cases.
 func Exists(name string) bool {
+	found, err := FileExists(name)
+	return found && err == nil
+}
+
+// FileExists tells whether the file exists.
+//
+// If it doesn't exist, it returns false and nil error. Other errors of os.Stat (permission denied etc.) are returned,
+// because the existence of the file is unknown then.
+func FileExists(name string) (bool, error) {
 	if _, err := os.Stat(name); err != nil {
 		if os.IsNotExist(err) {
-			return false
+			return false, nil
 		}
+		return false, err
 	}
-	return true
+	return true, nil
 }
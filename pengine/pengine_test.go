@@ -0,0 +1,186 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// testFiles returns the files used by most tests. Some of them compress well, one is empty.
+func testFiles() map[string][]byte {
+	return map[string][]byte{
+		"a.txt":   bytes.Repeat([]byte("hello paket "), 500),
+		"b.bin":   []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 250, 251, 252, 253, 254, 255},
+		"c.txt":   []byte("small file"),
+		"empty":   {},
+		"license": bytes.Repeat([]byte("MIT "), 50),
+	}
+}
+
+// writeTestFiles writes the files to a temporary folder and returns the folder and the paths, sorted by name.
+func writeTestFiles(t testing.TB, files map[string][]byte) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, 0, len(files))
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return dir, paths
+}
+
+// packTest packs the files with opts and returns the path of the paket and its table.
+func packTest(t testing.TB, files map[string][]byte, opts PackOptions) (string, Datas) {
+	t.Helper()
+	_, paths := writeTestFiles(t, files)
+	out := filepath.Join(t.TempDir(), "data.pack")
+	f, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := Pack(f, testKey, paths, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, table
+}
+
+// checkFiles reads every file of files from p and compares it with the original.
+func checkFiles(t *testing.T, p *Paket, files map[string][]byte) {
+	t.Helper()
+	for name, want := range files {
+		got, ok, err := p.GetFile(name, true, true)
+		if err != nil {
+			t.Fatalf("GetFile %s: %v", name, err)
+		}
+		if !ok {
+			t.Errorf("GetFile %s: hash doesn't match", name)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("GetFile %s: content is not the original file", name)
+		}
+	}
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	for _, mode := range []Mode{ModeCFB, ModeGCM} {
+		files := testFiles()
+		path, table := packTest(t, files, PackOptions{Mode: mode, Compression: CompressionGzip, MAC: true})
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = mode
+		checkFiles(t, p, files)
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := p.GetFile("a.txt", true, true); err != ErrClosed {
+			t.Errorf("GetFile after Close: %v, want ErrClosed", err)
+		}
+	}
+}
+
+func TestSwap(t *testing.T) {
+	oldFiles := testFiles()
+	oldPath, oldTable := packTest(t, oldFiles, PackOptions{})
+	newFiles := map[string][]byte{"a.txt": []byte("new content of a"), "d.txt": []byte("only in the new paket")}
+	newPath, newTable := packTest(t, newFiles, PackOptions{Compression: CompressionGzip})
+
+	p, err := New(testKey, oldPath, oldTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, oldFiles)
+
+	// a failed Swap keeps the old paket.
+	if err := p.Swap(filepath.Join(t.TempDir(), "missing.pack"), newTable); err == nil {
+		t.Fatal("Swap to a missing file succeeded")
+	}
+	checkFiles(t, p, oldFiles)
+
+	if err := p.Swap(newPath, newTable); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, p, newFiles)
+	if _, _, err := p.GetFile("b.bin", true, false); !errors.Is(err, ErrFileNotInTable) {
+		t.Errorf("file of the old paket after Swap: %v, want ErrFileNotInTable", err)
+	}
+}
+
+// Swap must not race with the functions reading the table. Run with -race.
+func TestSwapConcurrent(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	mac := TableMAC(testKey, table)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := p.Swap(path, table); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		p.Info()
+		if err := p.VerifyTableMAC(mac); err != nil {
+			t.Error(err)
+		}
+	}
+	<-done
+	checkFiles(t, p, files)
+}
+
+// Pakets that are not a single local file can't be swapped, their reads would return garbage.
+func TestSwapUnsupported(t *testing.T) {
+	files := testFiles()
+	dataPath, table := packTest(t, files, PackOptions{})
+	p, err := OpenSelfDescribing(testKey, writeSelfDescribing(t, dataPath, Header{Table: table}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Swap(dataPath, table); err == nil {
+		t.Fatal("Swap of a self-describing paket succeeded")
+	}
+	checkFiles(t, p, files)
+
+	data, err := ioutil.ReadFile(dataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewFromBytes(testKey, data, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Swap(dataPath, table); err == nil {
+		t.Error("Swap of a paket from memory succeeded")
+	}
+}
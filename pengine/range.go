@@ -0,0 +1,97 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ReadRange returns this error (wrapped with the file name) if off is out of the file or off or length is negative.
+var ErrOutOfRange = errors.New("range is out of the file")
+
+// ReadRange returns length bytes of the file starting from off. Ranges past the end of the file are shortened.
+// If decrypt is false, the range is of the encrypted data.
+//
+// CFB is decrypted from the block before off, so only the range is read, not the whole file.
+// Compressed files are decompressed from the beginning up to off, and GCM files are read completely (like GetFile).
+// For random access to such files, store them without compression in CFB mode.
+//
+// No hash checking is done.
+func (p *Paket) ReadRange(filename string, off, length int64, decrypt bool) ([]byte, error) {
+	file, section, err := p.section(filename)
+	if err != nil {
+		return nil, err
+	}
+	size := file.EncryptLenght
+	if decrypt {
+		size = file.OriginalLenght
+	}
+	if off < 0 || length < 0 || off > size {
+		return nil, fmt.Errorf("%w: %s (%d+%d, size %d)", ErrOutOfRange, filename, off, length, size)
+	}
+	if off+length > size {
+		length = size - off
+	}
+
+	switch {
+	case !decrypt, file.Unencrypted && file.compression() == CompressionNone:
+		return readSection(filename, file, io.NewSectionReader(section, off, length), length)
+	case !p.isCFB() && !file.Unencrypted:
+		content, _, err := p.GetFile(filename, true, false)
+		if err != nil {
+			return nil, err
+		}
+		// the table can be changed by Swap after the section was taken.
+		if int64(len(content)) < off+length {
+			return nil, fmt.Errorf("%w: %s", ErrLengthMismatch, filename)
+		}
+		return content[off : off+length], nil
+	case file.compression() != CompressionNone:
+		r, err := p.OpenReader(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if _, err := io.CopyN(ioutil.Discard, r, off); err != nil {
+			return nil, err
+		}
+		content := make([]byte, length)
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+
+	// In CFB, a block is decrypted with the previous encrypted block. The IV is the block before the first one.
+	// So decrypting can start from any block if the block before it is read too.
+	blockStart := off / aes.BlockSize * aes.BlockSize
+	n := aes.BlockSize + off + length - blockStart
+	content, err := readSection(filename, file, io.NewSectionReader(section, blockStart, n), n)
+	if err != nil {
+		return nil, err
+	}
+	block, err := p.fileBlock(filename)
+	if err != nil {
+		return nil, err
+	}
+	data := content[aes.BlockSize:]
+	cipher.NewCFBDecrypter(block, content[:aes.BlockSize]).XORKeyStream(data, data)
+	return data[off-blockStart:], nil
+}
+
+// readSection reads length bytes from r. Short reads return the error of regionError.
+func readSection(filename string, file Values, r io.Reader, length int64) ([]byte, error) {
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, regionError(filename, file.StartPos, file.EncryptLenght, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReadRange(t *testing.T) {
+	// ranges in the middle of a block, over blocks, at the end and past the end.
+	ranges := [][2]int64{{0, 0}, {0, 5}, {3, 20}, {16, 16}, {17, 100}, {5990, 100}, {6000, 10}}
+	for _, opts := range []PackOptions{
+		{},
+		{Mode: ModeGCM},
+		{Compression: CompressionGzip},
+		{Plain: []string{"*.txt"}},
+	} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		want := files["a.txt"]
+		for _, r := range ranges {
+			end := r[0] + r[1]
+			if end > int64(len(want)) {
+				end = int64(len(want))
+			}
+			got, err := p.ReadRange("a.txt", r[0], r[1], true)
+			if err != nil || !bytes.Equal(got, want[r[0]:end]) {
+				t.Errorf("%+v: ReadRange %d+%d: %v", opts, r[0], r[1], err)
+			}
+		}
+
+		enc, _, err := p.GetFile("a.txt", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := p.ReadRange("a.txt", 10, 30, false); err != nil || !bytes.Equal(got, enc[10:40]) {
+			t.Errorf("%+v: ReadRange without decrypt: %v", opts, err)
+		}
+		for _, r := range [][2]int64{{-1, 5}, {0, -1}, {int64(len(want)) + 1, 1}} {
+			if _, err := p.ReadRange("a.txt", r[0], r[1], true); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%+v: ReadRange %d+%d: %v, want ErrOutOfRange", opts, r[0], r[1], err)
+			}
+		}
+		p.Close()
+	}
+}
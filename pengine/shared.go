@@ -0,0 +1,89 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+// SharedBuffer is the decrypted content of a file, shared by all users of the same file.
+// It is created by GetShared.
+//
+// The bytes must not be modified. Every user sees the same slice.
+type SharedBuffer struct {
+	p    *Paket
+	name string
+	data []byte
+	// number of users. Protected by p.sharedMut.
+	refs int
+}
+
+// Bytes returns the decrypted content. Don't modify it and don't use it after Release.
+func (b *SharedBuffer) Bytes() []byte {
+	return b.data
+}
+
+// Release tells that the caller doesn't use the buffer anymore.
+// When all users release it, the Paket forgets the buffer and the memory can be freed.
+// If WipeOnClose of the Paket is true, the buffer is also overwritten with zeros.
+//
+// Calling Release more than once for the same GetShared call is a bug, extra calls are ignored.
+func (b *SharedBuffer) Release() {
+	b.p.sharedMut.Lock()
+	defer b.p.sharedMut.Unlock()
+	if b.refs <= 0 {
+		return
+	}
+	b.refs--
+	if b.refs == 0 {
+		// after Swap, the name can belong to a newer buffer.
+		if b.p.shared[b.name] == b {
+			delete(b.p.shared, b.name)
+		}
+		if b.p.WipeOnClose {
+			WipeKey(b.data)
+		}
+		b.data = nil
+	}
+}
+
+// GetShared returns the decrypted content of the file as a reference-counted buffer.
+//
+// If the file is already held by another caller, the same buffer is returned and the file isn't read again.
+// This way large files used by many parts of a program (e.g. a texture atlas) are in memory only once.
+//
+// Every GetShared call must be followed by a Release call. The buffer must not be modified.
+//
+// No hash checking is done, like GetFile with shaControl false.
+func (p *Paket) GetShared(name string) (*SharedBuffer, error) {
+	p.sharedMut.Lock()
+	if b, found := p.shared[name]; found {
+		b.refs++
+		p.sharedMut.Unlock()
+		return b, nil
+	}
+	p.sharedMut.Unlock()
+
+	// sharedMut is not held while reading, Close and Scrub take globMut before it.
+	data, _, err := p.GetFile(name, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	p.sharedMut.Lock()
+	defer p.sharedMut.Unlock()
+	if p.scrubbed {
+		WipeKey(data)
+		return nil, ErrClosed
+	}
+	// another caller can have read the same file in the meantime.
+	if b, found := p.shared[name]; found {
+		b.refs++
+		return b, nil
+	}
+	if p.shared == nil {
+		p.shared = make(map[string]*SharedBuffer)
+	}
+	b := &SharedBuffer{p: p, name: name, data: data, refs: 1}
+	p.shared[name] = b
+	return b, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultBufferSize is the size of the chunks of the streaming functions if BufferSize of Paket is 0.
+const DefaultBufferSize = 32 << 10
+
+// bufferSize returns BufferSize, or DefaultBufferSize if it is not set.
+func (p *Paket) bufferSize() int {
+	if p.BufferSize > 0 {
+		return p.BufferSize
+	}
+	return DefaultBufferSize
+}
+
+// OpenReader returns a reader that decrypts the file while it is read.
+// Unlike GetFile, the whole file is not loaded to memory. Use it for big files, e.g. with io.Copy to an http.ResponseWriter.
+//
+// No hash checking is done. Compressed files are also decompressed while reading.
+//
+// GCM can't be decrypted before the whole data is authenticated, so for ModeGCM the file is read to memory like GetFile.
+//
+// The reader must be closed. Reads after Close of the Paket return ErrClosed.
+func (p *Paket) OpenReader(filename string) (io.ReadCloser, error) {
+	if v, _ := p.Stat(filename); !p.isCFB() && !v.Unencrypted {
+		content, _, err := p.GetFile(filename, true, false)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	file, r, err := p.cfbReader(filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decompressReader(file.compression(), r)
+}
+
+// cfbReader returns a reader that decrypts the CFB file from off to the end. off is a position in the decrypted data,
+// before decompression. Like ReadRange, decrypting starts from the block before off, so the data before it is not read.
+// Unencrypted files are read as they are.
+func (p *Paket) cfbReader(filename string, off int64) (Values, io.Reader, error) {
+	file, section, err := p.section(filename)
+	if err != nil {
+		return Values{}, nil, err
+	}
+	if file.Unencrypted {
+		if off < 0 || off > file.EncryptLenght {
+			return Values{}, nil, fmt.Errorf("%w: %s (%d, size %d)", ErrOutOfRange, filename, off, file.EncryptLenght)
+		}
+		return file, bufio.NewReaderSize(io.NewSectionReader(section, off, file.EncryptLenght-off), p.bufferSize()), nil
+	}
+	if !p.isCFB() {
+		return Values{}, nil, fmt.Errorf("%s can't be decrypted while streaming, it is not CFB", filename)
+	}
+	if file.EncryptLenght < IVSize {
+		return Values{}, nil, fmt.Errorf("%w: %s", ErrShortCiphertext, filename)
+	}
+	if off < 0 || off > file.EncryptLenght-IVSize {
+		return Values{}, nil, fmt.Errorf("%w: %s (%d, size %d)", ErrOutOfRange, filename, off, file.EncryptLenght-IVSize)
+	}
+	// the IV of the first block is the encrypted block before it. For the first block, it is the IV.
+	blockStart := off / IVSize * IVSize
+	iv := make([]byte, IVSize)
+	if _, err := section.ReadAt(iv, blockStart); err != nil {
+		return Values{}, nil, regionError(filename, file.StartPos, file.EncryptLenght, err)
+	}
+	block, err := p.fileBlock(filename)
+	if err != nil {
+		return Values{}, nil, err
+	}
+	start := blockStart + IVSize
+	// the paket is read in chunks of bufferSize, also if the caller reads less at once.
+	data := bufio.NewReaderSize(io.NewSectionReader(section, start, file.EncryptLenght-start), p.bufferSize())
+	r := &cipher.StreamReader{S: cipher.NewCFBDecrypter(block, iv), R: data}
+	if _, err := io.CopyN(ioutil.Discard, r, off-blockStart); err != nil {
+		return Values{}, nil, regionError(filename, file.StartPos, file.EncryptLenght, err)
+	}
+	return file, r, nil
+}
+
+// WriteFileTo writes the content of the file to w and returns the number of bytes written.
+// If decrypt is false, the encrypted data is written.
+//
+// Unlike GetFile, the file is streamed (see OpenReader), so it works well with http.ResponseWriter, gzip.Writer etc.
+// No hash checking is done.
+//
+// The count is also returned with errors, so callers can log how much was written before a failure.
+//
+// (It is not named WriteTo, because that name belongs to the io.WriterTo interface with a different signature.)
+func (p *Paket) WriteFileTo(filename string, w io.Writer, decrypt bool) (int64, error) {
+	var r io.Reader
+	if decrypt {
+		rc, err := p.OpenReader(filename)
+		if err != nil {
+			return 0, err
+		}
+		defer rc.Close()
+		r = rc
+	} else {
+		section, err := p.RawSection(filename)
+		if err != nil {
+			return 0, err
+		}
+		r = section
+	}
+	return io.CopyBuffer(w, r, make([]byte, p.bufferSize()))
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func TestOpenReader(t *testing.T) {
+	for _, opts := range []PackOptions{
+		{},
+		{Mode: ModeGCM},
+		{Compression: CompressionGzip},
+		{Compression: CompressionZstd},
+		{Plain: []string{"*.txt"}},
+	} {
+		files := testFiles()
+		path, table := packTest(t, files, opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Mode = opts.Mode
+		// a buffer smaller than a block.
+		p.BufferSize = 7
+		for name, want := range files {
+			r, err := p.OpenReader(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(iotest.OneByteReader(r))
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%+v: OpenReader %s: %v", opts, name, err)
+			}
+			if err := r.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+		if _, err := p.OpenReader("missing"); !errors.Is(err, ErrFileNotInTable) {
+			t.Errorf("%+v: OpenReader of a missing file: %v, want ErrFileNotInTable", opts, err)
+		}
+		p.Close()
+	}
+}
+
+func TestOpenReaderClosed(t *testing.T) {
+	files := testFiles()
+	path, table := packTest(t, files, PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.BufferSize = 16
+	r, err := p.OpenReader("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := io.ReadFull(r, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	if _, err := ioutil.ReadAll(r); !errors.Is(err, ErrClosed) {
+		t.Errorf("reading after Close of the paket: %v, want ErrClosed", err)
+	}
+	if _, err := p.OpenReader("a.txt"); !errors.Is(err, ErrClosed) {
+		t.Errorf("OpenReader after Close: %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WriteTableJSON writes the table as a JSON object to w. Names of the files are the keys, in sorted order.
+// The names of the fields are the json tags of Values, empty optional fields are left out:
+//
+//	{
+//	  "a.txt": {"startPos": 0, "endPos": 27, "originalLength": 11, "encryptLength": 27, "hashOriginal": "...", "hashEncrypt": "..."}
+//	}
+//
+// Compression is written as its name ("gzip", "zstd"). Tools in other languages can read pakets with it.
+func WriteTableJSON(w io.Writer, d Datas) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// LoadTableJSON reads a table written by WriteTableJSON. Unknown fields are ignored.
+func LoadTableJSON(r io.Reader) (Datas, error) {
+	var d Datas
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, fmt.Errorf("table is not valid JSON: %w", err)
+	}
+	if d == nil {
+		d = Datas{}
+	}
+	return d, nil
+}
+
+// LoadTable reads the table from an index file written by WriteTableJSON (the -index parameter of the cmd tool).
+// With it, the table is read at run time and the go file of the table is not needed:
+//
+//	table, err := pengine.LoadTable("assets.idx")
+//	...
+//	p, err := pengine.New(key, "assets.paket", table)
+//
+// Changing the files then only needs a new paket and index, the program isn't compiled again.
+// Anyone who can change the index can change the table, check it with VerifyTableMAC.
+func LoadTable(indexPath string) (Datas, error) {
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := LoadTableJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", indexPath, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ErrInvalidTable is returned by ValidateTable for entries that can't be read correctly.
+var ErrInvalidTable = errors.New("table is invalid")
+
+// ValidateTable checks the structure of the table against the paket file, without reading the files.
+//
+// For every entry:
+//   - lengths and positions must not be negative,
+//   - EndPos must be StartPos + EncryptLenght,
+//   - the region must be inside the paket.
+//
+// Also the regions of the entries must not overlap.
+// A hand-built or corrupt table would otherwise return wrong data at read time.
+//
+// Entries are checked in sorted order. Returns an error wrapping ErrInvalidTable with the name of the first wrong entry.
+// If the size of the paket is unknown (NewFromReaderAt with a reader without a Size method), the end of every region is read instead.
+func (p *Paket) ValidateTable() error {
+	p.globMut.RLock()
+	defer p.globMut.RUnlock()
+	if p.reader == nil {
+		return ErrClosed
+	}
+	size, sizeKnown := readerSize(p.reader)
+
+	table := p.table()
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := table[name]
+		switch {
+		case v.StartPos < 0 || v.EndPos < 0 || v.EncryptLenght < 0 || v.OriginalLenght < 0:
+			return fmt.Errorf("%w: %s: negative position or length", ErrInvalidTable, name)
+		case v.EndPos-v.StartPos != v.EncryptLenght:
+			return fmt.Errorf("%w: %s: EndPos (%d) is not StartPos (%d) + EncryptLenght (%d)", ErrInvalidTable, name, v.EndPos, v.StartPos, v.EncryptLenght)
+		}
+		if sizeKnown {
+			if v.EndPos > size {
+				return fmt.Errorf("%w: %s: ends at %d, after the end of the paket (%d)", ErrInvalidTable, name, v.EndPos, size)
+			}
+		} else if v.EndPos > 0 {
+			var b [1]byte
+			if _, err := p.reader.ReadAt(b[:], v.EndPos-1); err != nil {
+				return fmt.Errorf("%w: %s: ends at %d, after the end of the paket", ErrInvalidTable, name, v.EndPos)
+			}
+		}
+	}
+
+	// sort by position, then every region must start after the end of the previous one.
+	sort.Slice(names, func(i, j int) bool {
+		a, b := table[names[i]], table[names[j]]
+		if a.StartPos != b.StartPos {
+			return a.StartPos < b.StartPos
+		}
+		return names[i] < names[j]
+	})
+	prev := ""
+	for _, name := range names {
+		v := table[name]
+		if v.EncryptLenght == 0 {
+			continue
+		}
+		if prev != "" && v.StartPos < table[prev].EndPos {
+			return fmt.Errorf("%w: %s: overlaps with %s", ErrInvalidTable, name, prev)
+		}
+		prev = name
+	}
+	return nil
+}
+
+// readerSize returns the size of r if it can be known.
+func readerSize(r interface{}) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), true
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fInfo, err := r.Stat(); err == nil {
+			return fInfo.Size(), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+// readerAtOnly hides the Size method of the reader, so the size of the paket is unknown.
+type readerAtOnly struct {
+	r *bytes.Reader
+}
+
+func (r readerAtOnly) ReadAt(b []byte, off int64) (int, error) { return r.r.ReadAt(b, off) }
+
+func TestValidateTable(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := int64(len(data))
+
+	tests := []struct {
+		name   string
+		change func(Datas)
+	}{
+		{"negative position", func(d Datas) {
+			v := d["a.txt"]
+			v.StartPos, v.EndPos = -1, v.EncryptLenght-1
+			d["a.txt"] = v
+		}},
+		{"wrong EndPos", func(d Datas) {
+			v := d["a.txt"]
+			v.EndPos++
+			d["a.txt"] = v
+		}},
+		{"after the end", func(d Datas) {
+			v := d["a.txt"]
+			v.StartPos, v.EndPos = size-v.EncryptLenght+1, size+1
+			d["a.txt"] = v
+		}},
+		{"overlap", func(d Datas) {
+			v := d["c.txt"]
+			v.StartPos, v.EndPos = d["b.bin"].StartPos+1, d["b.bin"].StartPos+1+v.EncryptLenght
+			d["c.txt"] = v
+		}},
+	}
+	for _, open := range []struct {
+		name string
+		new  func(Datas) (*Paket, error)
+	}{
+		{"New", func(d Datas) (*Paket, error) { return New(testKey, path, d) }},
+		{"unknown size", func(d Datas) (*Paket, error) {
+			return NewFromReaderAt(testKey, readerAtOnly{bytes.NewReader(data)}, d)
+		}},
+	} {
+		p, err := open.new(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ValidateTable(); err != nil {
+			t.Errorf("%s: ValidateTable of a correct table: %v", open.name, err)
+		}
+		p.Close()
+		for _, test := range tests {
+			d := make(Datas, len(table))
+			for k, v := range table {
+				d[k] = v
+			}
+			test.change(d)
+			p, err := open.new(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.ValidateTable(); !errors.Is(err, ErrInvalidTable) {
+				t.Errorf("%s: ValidateTable with %s: %v, want ErrInvalidTable", open.name, test.name, err)
+			}
+			p.Close()
+		}
+	}
+}
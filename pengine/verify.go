@@ -0,0 +1,98 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Verify checks the encrypted data of all files in the Paket against HashEncrypt in the table.
+//
+// The data is not decrypted, so it works even with a wrong key (except for MACs, see below).
+// It is a cheap health check after downloading a paket or at startup.
+//
+// Returns the sorted names of the files whose hash doesn't match. An empty slice means everything is fine.
+// Files without HashEncrypt (tables created with -m) can't be verified and are also returned.
+//
+// Files with a MAC in the table are checked with the MAC, it needs the right key.
+//
+// Hash and MAC mismatches don't stop the check, only read errors do. See VerifyContext.
+func (p *Paket) Verify() ([]string, error) {
+	return p.VerifyContext(context.Background())
+}
+
+// VerifyContext is Verify with a context. Files are read and hashed in parallel, runtime.GOMAXPROCS(0) at the same time,
+// so large pakets are checked with all cores.
+//
+// The first read error stops the other workers and is returned with the name of the file.
+// If ctx is cancelled, ctx.Err() is returned. The failed files found until then are returned with the errors.
+func (p *Paket) VerifyContext(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	names := p.Keys()
+	jobs := make(chan string)
+	var (
+		mut      sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	failed := []string{}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				_, ok, err := p.GetFileContext(ctx, name, false, true)
+				if errors.Is(err, ErrIntegrity) {
+					err, ok = nil, false
+				}
+				mut.Lock()
+				switch {
+				case err != nil:
+					if firstErr == nil {
+						firstErr = err
+						if err != ctx.Err() {
+							firstErr = fmt.Errorf("Verify %s: %w", name, err)
+						}
+						cancel()
+					}
+				case !ok:
+					failed = append(failed, name)
+				}
+				mut.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, name := range names {
+		select {
+		case jobs <- name:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// workers finish in any order.
+	sort.Strings(failed)
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return failed, firstErr
+}
@@ -0,0 +1,69 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	for _, opts := range []PackOptions{{}, {MAC: true}} {
+		path, table := packTest(t, testFiles(), opts)
+		p, err := New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed, err := p.Verify(); err != nil || len(failed) != 0 {
+			t.Errorf("%+v: Verify: %v, %v", opts, failed, err)
+		}
+		p.Close()
+
+		flipByte(t, path, table["license"].StartPos+IVSize)
+		flipByte(t, path, table["a.txt"].EndPos-1)
+		p, err = New(testKey, path, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed, err := p.Verify(); err != nil || !reflect.DeepEqual(failed, []string{"a.txt", "license"}) {
+			t.Errorf("%+v: Verify of modified files: %v, %v", opts, failed, err)
+		}
+		p.Close()
+	}
+}
+
+// Files without HashEncrypt can't be verified, they are returned.
+func TestVerifyMinimal(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{Minimal: true})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	failed, err := p.Verify()
+	if err != nil || !reflect.DeepEqual(failed, p.Keys()) {
+		t.Errorf("Verify: %v, %v, want all files", failed, err)
+	}
+}
+
+func TestVerifyContext(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	p, err := New(testKey, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.VerifyContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("VerifyContext with a cancelled context: %v, want context.Canceled", err)
+	}
+	p.Close()
+	if _, err := p.Verify(); err == nil {
+		t.Error("Verify after Close succeeded")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// VolumeName returns the file name of the nth volume (starting from 1) of a paket: name.001, name.002...
+// The cmd tool names the volumes with it (see the -split parameter).
+func VolumeName(name string, n int) string {
+	return fmt.Sprintf("%s.%03d", name, n)
+}
+
+// volumeReader reads the volumes of a paket as one file.
+type volumeReader struct {
+	files []*os.File
+	// starts[i] is the position of the first byte of files[i] in the whole paket.
+	starts []int64
+	size   int64
+}
+
+// ReadAt reads from the volume containing off, and from the next volumes if b doesn't fit into it.
+func (v *volumeReader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n := 0
+	for i := range v.files {
+		if len(b) == 0 {
+			break
+		}
+		end := v.size
+		if i+1 < len(v.starts) {
+			end = v.starts[i+1]
+		}
+		if off >= end {
+			continue
+		}
+		chunk := b
+		if rest := end - off; int64(len(chunk)) > rest {
+			chunk = chunk[:rest]
+		}
+		m, err := v.files[i].ReadAt(chunk, off-v.starts[i])
+		n += m
+		if err != nil && !(err == io.EOF && m == len(chunk)) {
+			return n, err
+		}
+		b = b[m:]
+		off += int64(m)
+	}
+	if len(b) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close closes all volumes. Returns the first error.
+func (v *volumeReader) Close() error {
+	var first error
+	for _, f := range v.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// NewVolumes creates a new Paket from a paket split into volumes (see VolumeWriter).
+// The volumes are read as one file, in the order of volumePaths. Files stored across two volumes are read from both.
+//
+// key and table parameters are the same as New. The positions in the table are the positions in the whole paket.
+//
+// Returns an error wrapping ErrPaketNotFound if a volume doesn't exist.
+// After getting all the data you need, should be terminated with Close. It closes all volumes.
+func NewVolumes(key []byte, volumePaths []string, table Datas) (*Paket, error) {
+	if err := ValidKeyLength(key); err != nil {
+		return nil, err
+	}
+	if len(volumePaths) == 0 {
+		return nil, errors.New("no volumes are given")
+	}
+	if len(table) == 0 {
+		return nil, ErrMinimumMapValue
+	}
+
+	v := &volumeReader{}
+	for _, path := range volumePaths {
+		f, err := os.Open(path)
+		if err != nil {
+			v.Close()
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%w: %s", ErrPaketNotFound, path)
+			}
+			return nil, err
+		}
+		v.files = append(v.files, f)
+		fInfo, err := f.Stat()
+		if err != nil {
+			v.Close()
+			return nil, err
+		}
+		v.starts = append(v.starts, v.size)
+		v.size += fInfo.Size()
+	}
+	if v.size == 0 {
+		v.Close()
+		return nil, fmt.Errorf("%w: %s", ErrEmptyPaket, volumePaths[0])
+	}
+	return &Paket{file: guardFile(v, volumePaths[0]), reader: v, Table: table, Key: key}, nil
+}
+
+// VolumeWriter is an io.Writer that splits the data into volume files of a maximum size.
+// When the current volume reaches the size, the next one is created. Volumes are named with VolumeName.
+//
+// Give it to Pack to create a split paket, and open the volumes with NewVolumes.
+// Files can be split across two volumes, the positions in the table are the positions in the whole paket.
+type VolumeWriter struct {
+	name  string
+	size  int64
+	cur   *os.File
+	n     int64
+	paths []string
+}
+
+// NewVolumeWriter creates a VolumeWriter. Volumes are named name.001, name.002... and are at most size bytes.
+//
+// Existing volumes are not overwritten, Write returns an error for them.
+func NewVolumeWriter(name string, size int64) (*VolumeWriter, error) {
+	if size < 1 {
+		return nil, errors.New("volume size must be greater than 0")
+	}
+	return &VolumeWriter{name: name, size: size}, nil
+}
+
+// Write writes b to the current volume, and to the next volumes if it doesn't fit.
+func (w *VolumeWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		if w.cur == nil || w.n == w.size {
+			if err := w.next(); err != nil {
+				return written, err
+			}
+		}
+		chunk := b
+		if rest := w.size - w.n; int64(len(chunk)) > rest {
+			chunk = chunk[:rest]
+		}
+		m, err := w.cur.Write(chunk)
+		written += m
+		w.n += int64(m)
+		if err != nil {
+			return written, err
+		}
+		b = b[m:]
+	}
+	return written, nil
+}
+
+// next closes the current volume and creates the next one.
+func (w *VolumeWriter) next() error {
+	if w.cur != nil {
+		if err := w.cur.Close(); err != nil {
+			return err
+		}
+		w.cur = nil
+	}
+	path := VolumeName(w.name, len(w.paths)+1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	w.cur, w.n = f, 0
+	w.paths = append(w.paths, path)
+	return nil
+}
+
+// Paths returns the names of the volumes created so far, in order. Give them to NewVolumes.
+func (w *VolumeWriter) Paths() []string {
+	return append([]string(nil), w.paths...)
+}
+
+// Close closes the current volume. The VolumeWriter can't be used after it.
+func (w *VolumeWriter) Close() error {
+	if w.cur == nil {
+		return nil
+	}
+	err := w.cur.Close()
+	w.cur = nil
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// packVolumes packs the files into volumes of size bytes and returns their paths and the table.
+func packVolumes(t *testing.T, files map[string][]byte, size int64) ([]string, Datas) {
+	t.Helper()
+	_, paths := writeTestFiles(t, files)
+	w, err := NewVolumeWriter(filepath.Join(t.TempDir(), "data.pack"), size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := Pack(w, testKey, paths, PackOptions{MAC: true})
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w.Paths(), table
+}
+
+func TestVolumes(t *testing.T) {
+	files := testFiles()
+	// a.txt is larger than a volume, so it is split across volumes.
+	volumes, table := packVolumes(t, files, 1000)
+	if len(volumes) < 3 {
+		t.Fatalf("%d volumes, want at least 3", len(volumes))
+	}
+	var total int64
+	for i, path := range volumes {
+		if want := VolumeName(volumes[0][:len(volumes[0])-4], i+1); path != want {
+			t.Errorf("volume %d: %s, want %s", i+1, path, want)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1000 {
+			t.Errorf("%s is %d bytes, larger than the volume size", path, info.Size())
+		}
+		total += info.Size()
+	}
+	for name, v := range table {
+		if v.EndPos > total {
+			t.Errorf("%s ends at %d, after the end of the volumes (%d)", name, v.EndPos, total)
+		}
+	}
+
+	p, err := NewVolumes(testKey, volumes, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkFiles(t, p, files)
+	if failed, err := p.Verify(); err != nil || len(failed) > 0 {
+		t.Errorf("Verify: %v, %v", failed, err)
+	}
+}
+
+func TestVolumesErrors(t *testing.T) {
+	if _, err := NewVolumeWriter("data.pack", 0); err == nil {
+		t.Error("NewVolumeWriter with size 0 succeeded")
+	}
+	volumes, table := packVolumes(t, testFiles(), 1000)
+
+	// existing volumes are not overwritten.
+	w, err := NewVolumeWriter(volumes[0][:len(volumes[0])-4], 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("data")); !errors.Is(err, os.ErrExist) {
+		t.Errorf("Write to an existing volume: %v, want os.ErrExist", err)
+	}
+	w.Close()
+
+	missing := append(volumes[:1:1], filepath.Join(t.TempDir(), "data.pack.002"))
+	if _, err := NewVolumes(testKey, missing, table); !errors.Is(err, ErrPaketNotFound) {
+		t.Errorf("NewVolumes with a missing volume: %v, want ErrPaketNotFound", err)
+	}
+}
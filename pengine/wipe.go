@@ -0,0 +1,53 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+// WipeKey overwrites the key with zeros. Setting a slice to nil doesn't remove its bytes from memory, this does.
+//
+// Call it when the key is not needed anymore. The key can't be used after it.
+func WipeKey(key []byte) {
+	for i := range key {
+		key[i] = 0
+	}
+}
+
+// Scrub overwrites the Key and the buffers given by GetShared with zeros, and forgets them.
+// Buffers returned by GetFile and other functions belong to the caller, wipe them with WipeKey.
+//
+// Reading functions return ErrClosed after Scrub, like after Close. Only Close can be called, Reopen doesn't work.
+// Set WipeOnClose to scrub automatically in Close.
+func (p *Paket) Scrub() {
+	p.globMut.Lock()
+	defer p.globMut.Unlock()
+	p.scrub()
+	// the key is zero now, reads would return garbage. The file is closed by Close.
+	p.reader = nil
+}
+
+// scrub is Scrub without locking globMut.
+// globMut must be held. sharedMut is taken after it, never before (see GetShared).
+func (p *Paket) scrub() {
+	WipeKey(p.Key)
+
+	p.sharedMut.Lock()
+	p.scrubbed = true
+	for name, b := range p.shared {
+		WipeKey(b.data)
+		b.data = nil
+		delete(p.shared, name)
+	}
+	p.sharedMut.Unlock()
+
+	p.ivMut.Lock()
+	p.ivs, p.ivWarned = nil, nil
+	p.ivMut.Unlock()
+
+	// the key schedule is the key in another form.
+	p.crypterMut.Lock()
+	WipeKey(p.crypterKey)
+	p.crypter, p.crypterKey = nil, nil
+	p.crypterMut.Unlock()
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScrub(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	key := append([]byte(nil), testKey...)
+	p, err := New(key, path, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	b, err := p.GetShared("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Scrub()
+	if !bytes.Equal(key, make([]byte, len(key))) {
+		t.Error("Scrub didn't wipe the key")
+	}
+	if b.Bytes() != nil {
+		t.Error("Scrub didn't wipe the shared buffer")
+	}
+	if _, _, err := p.GetFile("a.txt", true, false); err != ErrClosed {
+		t.Errorf("GetFile after Scrub: %v, want ErrClosed", err)
+	}
+	if _, err := p.GetShared("a.txt"); err != ErrClosed {
+		t.Errorf("GetShared after Scrub: %v, want ErrClosed", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reopen(); err == nil {
+		t.Error("Reopen after Scrub succeeded")
+	}
+}
+
+// gatedReader blocks ReadAt until gate is closed. entered is closed at the first ReadAt.
+type gatedReader struct {
+	r       *bytes.Reader
+	gate    chan struct{}
+	entered chan struct{}
+	once    sync.Once
+}
+
+func (g *gatedReader) ReadAt(b []byte, off int64) (int, error) {
+	g.once.Do(func() { close(g.entered) })
+	<-g.gate
+	return g.r.ReadAt(b, off)
+}
+
+// Close with WipeOnClose takes globMut and then sharedMut. GetShared must not hold sharedMut while it waits for globMut,
+// otherwise Close, GetShared and GetFile running at the same time deadlock.
+func TestCloseWipeWithGetShared(t *testing.T) {
+	path, table := packTest(t, testFiles(), PackOptions{})
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &gatedReader{r: bytes.NewReader(data), gate: make(chan struct{}), entered: make(chan struct{})}
+	p, err := NewFromReaderAt(append([]byte(nil), testKey...), r, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.WipeOnClose = true
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		wg.Add(3)
+		// a read in progress holds the read lock of globMut.
+		go func() {
+			defer wg.Done()
+			p.GetFile("b.bin", true, false)
+		}()
+		<-r.entered
+		// Close waits for the read.
+		go func() {
+			defer wg.Done()
+			p.Close()
+		}()
+		time.Sleep(50 * time.Millisecond)
+		// GetShared waits for globMut behind Close.
+		go func() {
+			defer wg.Done()
+			if b, err := p.GetShared("a.txt"); err == nil {
+				b.Release()
+			}
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(r.gate)
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock between Close, GetShared and GetFile")
+	}
+}
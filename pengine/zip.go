@@ -0,0 +1,90 @@
+// Copyright (C) 2021 SeanTolstoyevski -  mailto:seantolstoyevski@protonmail.com
+// The source code of this project is licensed under the MIT license.
+// You can find the license on the repo's main folder.
+// Provided without warranty of any kind.
+
+package pengine
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ZipBufferLimit is the maximum size of a compressed zip entry that NewFromZip loads to memory.
+//
+// Stored (uncompressed) entries are read directly from the zip file and are not limited.
+var ZipBufferLimit int64 = 256 << 20
+
+// NewFromZip creates a new Paket from a paket file stored inside a zip archive.
+//
+// zipPath is the path of the zip file, entryName is the name of the paket file in the zip.
+//
+// If the entry is stored without compression, it is read directly from the zip file like a normal paket.
+// Otherwise, it is decompressed to memory. Entries bigger than ZipBufferLimit return an error.
+// Store the paket uncompressed in the zip (it is encrypted, so it doesn't compress anyway).
+//
+// key and table parameters are the same as New.
+// After getting all the data you need, should be terminated with  Close.
+func NewFromZip(key []byte, zipPath, entryName string, table Datas) (*Paket, error) {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	fInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, fInfo.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	var entry *zip.File
+	for _, zf := range zr.File {
+		if zf.Name == entryName {
+			entry = zf
+			break
+		}
+	}
+	if entry == nil {
+		f.Close()
+		return nil, fmt.Errorf("%s not found in zip file %s", entryName, zipPath)
+	}
+
+	if entry.Method == zip.Store {
+		offset, err := entry.DataOffset()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		p, err := NewFromReaderAt(key, io.NewSectionReader(f, offset, int64(entry.UncompressedSize64)), table)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// Close releases the zip file.
+		p.file = guardFile(f, zipPath)
+		return p, nil
+	}
+
+	// The entry is compressed. It can only be read sequentially, so we load it to memory.
+	defer f.Close()
+	if entry.UncompressedSize64 > uint64(ZipBufferLimit) {
+		return nil, fmt.Errorf("%s is compressed and too large to buffer (%d bytes, limit %d). Store it uncompressed in the zip", entryName, entry.UncompressedSize64, ZipBufferLimit)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data := make([]byte, entry.UncompressedSize64)
+	if _, err := io.ReadFull(rc, data); err != nil {
+		return nil, err
+	}
+	return NewFromReaderAt(key, bytes.NewReader(data), table)
+}